@@ -0,0 +1,290 @@
+// Package qr кодирует и разбирает данные платёжного QR-кода в формате,
+// близком к EMVCo Merchant-Presented Mode: последовательность полей
+// TLV (два символа тега, два символа длины, значение) с контрольной суммой
+// CRC-16/CCITT-FALSE в последнем поле.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrInvalidPayload = errors.New("invalid qr payload")
+var ErrChecksum = errors.New("qr payload checksum mismatch")
+var ErrUnknownScheme = errors.New("qr payload is not a wallet payment")
+
+// Scheme - идентификатор кошелька в шаблоне данных получателя.
+const Scheme = "wallet.muhamadi02"
+
+// DefaultCurrency - код валюты по ISO 4217 (сомони).
+const DefaultCurrency = "972"
+
+// Теги полей.
+const (
+	tagFormat       = "00"
+	tagInitiation   = "01"
+	tagMerchant     = "26"
+	tagCurrency     = "53"
+	tagAmount       = "54"
+	tagMerchantName = "59"
+	tagAdditional   = "62"
+	tagCRC          = "63"
+
+	// вложенные поля шаблона получателя
+	tagScheme     = "00"
+	tagMerchantID = "01"
+
+	// вложенные поля дополнительных данных
+	tagReference = "01"
+	tagCategory  = "08"
+)
+
+const (
+	formatVersion = "01"
+	staticCode    = "11" // сумму вводит плательщик
+	dynamicCode   = "12" // сумма зашита в код
+)
+
+// Payload - данные платёжного QR-кода.
+type Payload struct {
+	MerchantID   string
+	MerchantName string
+	Amount       types.Money // 0 - сумму вводит плательщик
+	Category     types.PaymentCategory
+	Currency     string // код ISO 4217, по умолчанию DefaultCurrency
+	Reference    string // номер счёта на оплату или чека
+}
+
+// Validate - проверяет, что данные можно закодировать и оплатить.
+func (p Payload) Validate() error {
+	if p.MerchantID == "" || p.Amount < 0 {
+		return ErrInvalidPayload
+	}
+	if p.Currency != "" && (len(p.Currency) != 3 || !isDigits(p.Currency)) {
+		return ErrInvalidPayload
+	}
+	return nil
+}
+
+// Encode - кодирует данные в строку для QR-кода.
+func Encode(p Payload) (string, error) {
+	err := p.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	currency := p.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	initiation := staticCode
+	if p.Amount > 0 {
+		initiation = dynamicCode
+	}
+
+	merchant, err := field(tagScheme, Scheme)
+	if err != nil {
+		return "", err
+	}
+	merchantID, err := field(tagMerchantID, p.MerchantID)
+	if err != nil {
+		return "", err
+	}
+
+	fields := [][2]string{
+		{tagFormat, formatVersion},
+		{tagInitiation, initiation},
+		{tagMerchant, merchant + merchantID},
+		{tagCurrency, currency},
+	}
+	if p.Amount > 0 {
+		fields = append(fields, [2]string{tagAmount, formatAmount(p.Amount)})
+	}
+	if p.MerchantName != "" {
+		fields = append(fields, [2]string{tagMerchantName, p.MerchantName})
+	}
+
+	additional := ""
+	for _, sub := range [][2]string{{tagReference, p.Reference}, {tagCategory, string(p.Category)}} {
+		if sub[1] == "" {
+			continue
+		}
+		encoded, err := field(sub[0], sub[1])
+		if err != nil {
+			return "", err
+		}
+		additional += encoded
+	}
+	if additional != "" {
+		fields = append(fields, [2]string{tagAdditional, additional})
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		encoded, err := field(f[0], f[1])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(encoded)
+	}
+
+	b.WriteString(tagCRC + "04")
+	b.WriteString(fmt.Sprintf("%04X", crc16(b.String())))
+	return b.String(), nil
+}
+
+// Decode - разбирает и проверяет строку из QR-кода.
+func Decode(data string) (Payload, error) {
+	if utf8.RuneCountInString(data) < 8 || !strings.HasSuffix(data[:len(data)-4], tagCRC+"04") {
+		return Payload{}, ErrInvalidPayload
+	}
+	sum, err := strconv.ParseUint(data[len(data)-4:], 16, 16)
+	if err != nil {
+		return Payload{}, ErrInvalidPayload
+	}
+	if uint16(sum) != crc16(data[:len(data)-4]) {
+		return Payload{}, ErrChecksum
+	}
+
+	fields, err := parseFields(data[:len(data)-8])
+	if err != nil {
+		return Payload{}, err
+	}
+	if fields[tagFormat] != formatVersion {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	merchant, err := parseFields(fields[tagMerchant])
+	if err != nil {
+		return Payload{}, err
+	}
+	if merchant[tagScheme] != Scheme {
+		return Payload{}, ErrUnknownScheme
+	}
+
+	payload := Payload{
+		MerchantID:   merchant[tagMerchantID],
+		MerchantName: fields[tagMerchantName],
+		Currency:     fields[tagCurrency],
+	}
+
+	switch fields[tagInitiation] {
+	case staticCode:
+		if fields[tagAmount] != "" {
+			return Payload{}, ErrInvalidPayload
+		}
+	case dynamicCode:
+		payload.Amount, err = parseAmount(fields[tagAmount])
+		if err != nil || payload.Amount == 0 {
+			return Payload{}, ErrInvalidPayload
+		}
+	default:
+		return Payload{}, ErrInvalidPayload
+	}
+
+	if fields[tagAdditional] != "" {
+		additional, err := parseFields(fields[tagAdditional])
+		if err != nil {
+			return Payload{}, err
+		}
+		payload.Reference = additional[tagReference]
+		payload.Category = types.PaymentCategory(additional[tagCategory])
+	}
+
+	err = payload.Validate()
+	if err != nil {
+		return Payload{}, err
+	}
+	return payload, nil
+}
+
+// field кодирует одно поле TLV. Длина считается в символах и не может
+// превышать 99.
+func field(tag string, value string) (string, error) {
+	length := utf8.RuneCountInString(value)
+	if length > 99 {
+		return "", ErrInvalidPayload
+	}
+	return fmt.Sprintf("%s%02d%s", tag, length, value), nil
+}
+
+// parseFields разбирает последовательность полей TLV. Повторяющиеся теги
+// считаются ошибкой.
+func parseFields(data string) (map[string]string, error) {
+	fields := make(map[string]string)
+	rest := []rune(data)
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, ErrInvalidPayload
+		}
+
+		tag := string(rest[:2])
+		length, err := strconv.Atoi(string(rest[2:4]))
+		if err != nil || !isDigits(tag) || length < 0 || len(rest) < 4+length {
+			return nil, ErrInvalidPayload
+		}
+		if _, ok := fields[tag]; ok {
+			return nil, ErrInvalidPayload
+		}
+
+		fields[tag] = string(rest[4 : 4+length])
+		rest = rest[4+length:]
+	}
+	return fields, nil
+}
+
+// formatAmount записывает сумму в минимальных единицах как "123.45".
+func formatAmount(amount types.Money) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
+
+// parseAmount разбирает сумму вида "123", "123.4" или "123.45".
+func parseAmount(value string) (types.Money, error) {
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || !isDigits(whole) || len(fraction) > 2 || (fraction != "" && !isDigits(fraction)) {
+		return 0, ErrInvalidPayload
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidPayload
+	}
+	cents := int64(0)
+	if fraction != "" {
+		cents, _ = strconv.ParseInt(fraction, 10, 64)
+		if len(fraction) == 1 {
+			cents *= 10
+		}
+	}
+	return types.Money(units*100 + cents), nil
+}
+
+// crc16 считает CRC-16/CCITT-FALSE (полином 0x1021, начальное значение 0xFFFF).
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
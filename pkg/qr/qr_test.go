@@ -0,0 +1,120 @@
+package qr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_crc16(t *testing.T) {
+	got := crc16("123456789")
+	if got != 0x29B1 {
+		t.Errorf("crc16() = %X, want 29B1", got)
+	}
+}
+
+func TestEncode_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload Payload
+	}{
+		{name: "static", payload: Payload{MerchantID: "m-1", Currency: DefaultCurrency}},
+		{
+			name: "dynamic",
+			payload: Payload{
+				MerchantID:   "8f0c7a52-3bb1-4f0e-9d0e-0d5f5a6b7c8d",
+				MerchantName: "Чайхана Рохат",
+				Amount:       123_45,
+				Category:     "restaurant",
+				Currency:     DefaultCurrency,
+				Reference:    "INV-42",
+			},
+		},
+		{name: "whole amount", payload: Payload{MerchantID: "m-1", Amount: 5_00, Currency: "840"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Encode(tt.payload)
+			if err != nil {
+				t.Errorf("Encode(): error = %v", err)
+				return
+			}
+			got, err := Decode(data)
+			if err != nil {
+				t.Errorf("Decode(): error = %v, data = %v", err, data)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.payload) {
+				t.Errorf("Decode() = %v, want %v", got, tt.payload)
+			}
+		})
+	}
+}
+
+func TestEncode_format(t *testing.T) {
+	data, err := Encode(Payload{MerchantID: "m-1", Amount: 10_50})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	want := "000201" + "010212" + "2628" + "0017wallet.muhamadi02" + "0103m-1" + "5303972" + "540510.50" + "6304"
+	if data[:len(data)-4] != want {
+		t.Errorf("Encode() = %v, want prefix %v", data, want)
+	}
+}
+
+func TestDecode_invalid(t *testing.T) {
+	valid, err := Encode(Payload{MerchantID: "m-1", Amount: 10_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := Encode(Payload{MerchantID: "m-1"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	foreign := "000201010211" + "2614" + "0010other.bank" + "5303972" + "6304"
+	foreign += crcHex(foreign)
+	noAmount := "000201010212" + "2628" + "0017wallet.muhamadi02" + "0103m-1" + "5303972" + "6304"
+	noAmount += crcHex(noAmount)
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{name: "empty", data: "", want: ErrInvalidPayload},
+		{name: "tampered", data: valid[:32] + "9" + valid[33:], want: ErrChecksum},
+		{name: "no checksum", data: other[:len(other)-8], want: ErrInvalidPayload},
+		{name: "foreign scheme", data: foreign, want: ErrUnknownScheme},
+		{name: "dynamic without amount", data: noAmount, want: ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data)
+			if err != tt.want {
+				t.Errorf("Decode(): must return %v, returned: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEncode_invalid(t *testing.T) {
+	_, err := Encode(Payload{Amount: 10_00})
+	if err != ErrInvalidPayload {
+		t.Errorf("Encode(): must return ErrInvalidPayload, returned: %v", err)
+	}
+	_, err = Encode(Payload{MerchantID: "m-1", Currency: "TJS"})
+	if err != ErrInvalidPayload {
+		t.Errorf("Encode(): must return ErrInvalidPayload, returned: %v", err)
+	}
+}
+
+func crcHex(data string) string {
+	const digits = "0123456789ABCDEF"
+	sum := crc16(data)
+	return string([]byte{digits[sum>>12&0xF], digits[sum>>8&0xF], digits[sum>>4&0xF], digits[sum&0xF]})
+}
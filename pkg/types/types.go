@@ -18,27 +18,353 @@ const (
 
 // Payment представляет информацию о платеже.
 type Payment struct {
-	ID        string
-	AccountID int64
-	Amount    Money
-	Category  PaymentCategory
-	Status    PaymentStatus
+	ID          string
+	AccountID   int64
+	Amount      Money
+	Category    PaymentCategory
+	Status      PaymentStatus
+	Created     int64  // время создания платежа (unix)
+	Description string // примечание к платежу
+	FavoriteID  string // избранное, из которого совершён платёж
+	MerchantID  string // получатель платежа
+	Channel     PaymentChannel
+	Fee         Money             // комиссия, списанная сверх суммы платежа
+	InvoiceID   string            // счёт на оплату, который оплачивает платёж
+	Tags        []string          // произвольные метки
+	ExternalRef string            // номер заказа во внешней системе
+	Metadata    map[string]string // произвольные данные "ключ - значение"
 }
 
+// PaymentChannel представляет собой канал, через который совершена операция.
+type PaymentChannel string
+
+// Предопределённые каналы.
+const (
+	ChannelApp      PaymentChannel = "APP"
+	ChannelWeb      PaymentChannel = "WEB"
+	ChannelPOS      PaymentChannel = "POS"
+	ChannelQR       PaymentChannel = "QR"
+	ChannelTransfer PaymentChannel = "TRANSFER" // переводы между счетами
+)
+
 type Phone string
 
+// AccountStatus представляет собой статус аккаунта.
+type AccountStatus string
+
+// Предопределённые статусы аккаунта.
+const (
+	AccountStatusActive  AccountStatus = "ACTIVE"
+	AccountStatusFrozen  AccountStatus = "FROZEN"  // списания запрещены
+	AccountStatusBlocked AccountStatus = "BLOCKED" // запрещены и списания, и зачисления
+	AccountStatusClosed  AccountStatus = "CLOSED"
+)
+
+// AccountType представляет собой вид счёта.
+type AccountType string
+
+// Предопределённые виды счетов.
+const (
+	AccountTypeCurrent AccountType = "CURRENT"
+	AccountTypeSavings AccountType = "SAVINGS"
+)
+
 // Account представляет информацию о счёте пользователя.
 type Account struct {
-	ID      int64
-	Phone   Phone
-	Balance Money
+	ID           int64
+	Phone        Phone
+	Balance      Money // общий баланс, включая заблокированные суммы
+	Held         Money // сумма, заблокированная холдами
+	Status       AccountStatus
+	CustomerID   int64  // владелец счёта
+	Name         string // название кошелька (main, savings, travel)
+	Type         AccountType
+	InterestRate int64 // годовая ставка в базисных пунктах (100 = 1%)
+	Accrued      int64 // начисленные, но ещё не выплаченные проценты в миллионных долях Money
+	AccruedAt    int64 // начало дня, до которого начислены проценты (unix)
+	CreditLimit  Money // одобренный овердрафт: насколько баланс может уйти в минус
+	ChargedAt    int64 // начало дня, до которого списана плата за овердрафт (unix)
+}
+
+// Available возвращает доступную для платежей сумму с учётом овердрафта.
+func (a *Account) Available() Money {
+	return a.Balance - a.Held + a.CreditLimit
+}
+
+// AvailableCredit возвращает неиспользованную часть овердрафта.
+func (a *Account) AvailableCredit() Money {
+	available := a.Available()
+	if available < 0 {
+		return 0
+	}
+	if available > a.CreditLimit {
+		return a.CreditLimit
+	}
+	return available
 }
 
 // Favorite представляет информацию о Избранных.
 type Favorite struct {
-	ID string
+	ID        string
+	AccountID int64
+	Name      string
+	Amount    Money
+	Category  PaymentCategory
+	Position  int    // порядок в списке избранного аккаунта
+	Payee     string // реквизиты получателя (номер телефона, лицевой счёт и т.п.)
+}
+
+// BudgetPeriod представляет собой период, на который задаётся бюджет.
+type BudgetPeriod string
+
+// Предопределённые периоды бюджета.
+const (
+	BudgetPeriodDaily   BudgetPeriod = "DAILY"
+	BudgetPeriodWeekly  BudgetPeriod = "WEEKLY"
+	BudgetPeriodMonthly BudgetPeriod = "MONTHLY"
+)
+
+// Budget представляет информацию о бюджете аккаунта на категорию платежей.
+type Budget struct {
+	ID        string
+	AccountID int64
+	Category  PaymentCategory
+	Period    BudgetPeriod
+	Amount    Money
+}
+
+// HoldStatus представляет собой статус холда.
+type HoldStatus string
+
+// Предопределённые статусы холда.
+const (
+	HoldStatusActive   HoldStatus = "ACTIVE"
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	HoldStatusReleased HoldStatus = "RELEASED"
+	HoldStatusExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold представляет информацию о предварительной блокировке средств на счёте.
+type Hold struct {
+	ID        string
+	AccountID int64
+	Amount    Money
+	Fee       Money // комиссия за списание, заблокированная вместе с суммой
+	Category  PaymentCategory
+	Status    HoldStatus
+	PaymentID string // платёж, созданный при списании
+	Created   int64
+	Expires   int64 // время истечения холда (unix)
+}
+
+// ScheduleKind представляет собой вид расписания платежа.
+type ScheduleKind string
+
+// Предопределённые виды расписаний.
+const (
+	ScheduleOnce    ScheduleKind = "ONCE"
+	ScheduleDaily   ScheduleKind = "DAILY"
+	ScheduleWeekly  ScheduleKind = "WEEKLY"
+	ScheduleMonthly ScheduleKind = "MONTHLY"
+	ScheduleCron    ScheduleKind = "CRON"
+)
+
+// Schedule представляет информацию о расписании платежа из избранного.
+type Schedule struct {
+	ID         string
+	FavoriteID string
+	Kind       ScheduleKind
+	Cron       string // выражение вида "мин час день месяц день_недели" для ScheduleCron
+	Start      int64  // первое выполнение (unix)
+	Due        int64  // текущее плановое выполнение (unix)
+	Next       int64  // время следующей попытки (unix)
+	Attempts   int    // неудачные попытки текущего выполнения
+	LastError  string
+	Active     bool
+}
+
+// Merchant представляет информацию о получателе платежей (магазин, поставщик услуг).
+type Merchant struct {
+	ID        string
+	Name      string
+	Category  PaymentCategory
+	AccountID int64 // счёт, на который зачисляются платежи
+}
+
+// Category представляет информацию о категории платежей из справочника.
+type Category struct {
+	Code   PaymentCategory
+	Name   string
+	Parent PaymentCategory // пустой код - категория верхнего уровня
+	Active bool
+}
+
+// PhoneChange представляет информацию о смене номера телефона аккаунта.
+type PhoneChange struct {
 	AccountID int64
-	Name string
-	Amount Money
-	Categoty PaymentCategory
+	OldPhone  Phone
+	NewPhone  Phone
+	Changed   int64 // время смены номера (unix)
+}
+
+// Customer представляет информацию о клиенте, владеющем несколькими счетами.
+type Customer struct {
+	ID               int64
+	Phone            Phone
+	DefaultAccountID int64 // основной счёт, создаваемый при регистрации
+}
+
+// LedgerKind представляет собой вид движения денег по счёту.
+type LedgerKind string
+
+// Предопределённые виды движений.
+const (
+	LedgerDeposit     LedgerKind = "DEPOSIT"
+	LedgerInterest    LedgerKind = "INTEREST"
+	LedgerTransferIn  LedgerKind = "TRANSFER_IN"
+	LedgerTransferOut LedgerKind = "TRANSFER_OUT"
+	LedgerOverdraft   LedgerKind = "OVERDRAFT_FEE"
+	LedgerFee         LedgerKind = "FEE"
+	LedgerCashback    LedgerKind = "CASHBACK"
+)
+
+// LedgerEntry представляет информацию о движении денег по счёту, кроме платежей.
+type LedgerEntry struct {
+	ID        string
+	AccountID int64
+	Amount    Money // положительная сумма - зачисление, отрицательная - списание
+	Kind      LedgerKind
+	Reference string // связанный объект (счёт перевода, ваучер и т.п.)
+	Created   int64
+}
+
+// RewardStatus представляет собой статус кэшбэка.
+type RewardStatus string
+
+// Предопределённые статусы кэшбэка.
+const (
+	RewardStatusPending    RewardStatus = "PENDING"     // ждёт окончания периода удержания
+	RewardStatusCredited   RewardStatus = "CREDITED"    // зачислен на счёт
+	RewardStatusCancelled  RewardStatus = "CANCELLED"   // платёж отклонён до зачисления
+	RewardStatusClawedBack RewardStatus = "CLAWED_BACK" // платёж отклонён после зачисления, кэшбэк списан
+)
+
+// Reward представляет информацию о кэшбэке за платёж.
+type Reward struct {
+	ID        string
+	AccountID int64
+	PaymentID string
+	Amount    Money
+	Status    RewardStatus
+	Created   int64 // время подтверждения платежа (unix)
+	CreditAt  int64 // время, после которого кэшбэк можно зачислить (unix)
+}
+
+// MoneyRequestStatus представляет собой статус запроса денег.
+type MoneyRequestStatus string
+
+// Предопределённые статусы запроса денег.
+const (
+	MoneyRequestPending  MoneyRequestStatus = "PENDING"
+	MoneyRequestAccepted MoneyRequestStatus = "ACCEPTED"
+	MoneyRequestDeclined MoneyRequestStatus = "DECLINED"
+	MoneyRequestExpired  MoneyRequestStatus = "EXPIRED"
+)
+
+// MoneyRequest представляет информацию о запросе денег у другого аккаунта.
+type MoneyRequest struct {
+	ID          string
+	RequesterID int64 // счёт, на который придут деньги
+	PayerID     int64 // счёт, с которого запрошены деньги
+	Amount      Money
+	Note        string
+	Status      MoneyRequestStatus
+	Created     int64  // время создания запроса (unix)
+	Expires     int64  // время, после которого запрос нельзя принять (unix)
+	PaymentID   string // платёж, который делят между участниками
+}
+
+// Voucher представляет информацию о предоплаченном ваучере (подарочном коде).
+type Voucher struct {
+	Code    string
+	Amount  Money
+	MaxUses int // сколько раз ваучер можно погасить (1 - одноразовый)
+	Uses    int
+	Created int64 // время выпуска (unix)
+	Expires int64 // время, после которого ваучер нельзя погасить (unix)
+}
+
+// InvoiceStatus представляет собой статус счёта на оплату.
+type InvoiceStatus string
+
+// Предопределённые статусы счёта на оплату.
+const (
+	InvoiceStatusOpen          InvoiceStatus = "OPEN"
+	InvoiceStatusPartiallyPaid InvoiceStatus = "PARTIALLY_PAID"
+	InvoiceStatusPaid          InvoiceStatus = "PAID"
+	InvoiceStatusOverdue       InvoiceStatus = "OVERDUE" // срок прошёл, а счёт оплачен не полностью
+	InvoiceStatusCancelled     InvoiceStatus = "CANCELLED"
+)
+
+// InvoiceItem представляет информацию о позиции счёта на оплату.
+type InvoiceItem struct {
+	Name     string
+	Quantity int64
+	Price    Money // цена за единицу
+}
+
+// Invoice представляет информацию о счёте на оплату, выставленном получателем.
+type Invoice struct {
+	ID         string
+	MerchantID string
+	Amount     Money // сумма всех позиций
+	Paid       Money // оплаченная часть
+	Due        int64 // срок оплаты (unix)
+	Items      []InvoiceItem
+	Status     InvoiceStatus
+	Created    int64 // время выставления (unix)
+}
+
+// InstallmentStatus представляет собой статус платежа по рассрочке.
+type InstallmentStatus string
+
+// Предопределённые статусы платежа по рассрочке.
+const (
+	InstallmentPending InstallmentStatus = "PENDING"
+	InstallmentPaid    InstallmentStatus = "PAID"
+	InstallmentOverdue InstallmentStatus = "OVERDUE" // срок прошёл, списать не удалось
+)
+
+// Installment представляет информацию об одном платеже по рассрочке.
+type Installment struct {
+	Number    int // порядковый номер, начиная с 1
+	Amount    Money
+	Due       int64 // срок платежа (unix)
+	Status    InstallmentStatus
+	PaymentID string
+	LateFee   Money // штраф за просрочку сверх льготного периода
+}
+
+// InstallmentPlanStatus представляет собой статус рассрочки.
+type InstallmentPlanStatus string
+
+// Предопределённые статусы рассрочки.
+const (
+	InstallmentPlanActive    InstallmentPlanStatus = "ACTIVE"
+	InstallmentPlanOverdue   InstallmentPlanStatus = "OVERDUE"
+	InstallmentPlanCompleted InstallmentPlanStatus = "COMPLETED"
+)
+
+// InstallmentPlan представляет информацию о покупке в рассрочку.
+type InstallmentPlan struct {
+	ID           string
+	AccountID    int64
+	Total        Money
+	Category     PaymentCategory
+	MerchantID   string
+	Description  string
+	Channel      PaymentChannel
+	Status       InstallmentPlanStatus
+	Created      int64 // время покупки (unix)
+	Installments []Installment
 }
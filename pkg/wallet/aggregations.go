@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+// GroupBy - признак, по которому AggregatePayments группирует платежи.
+type GroupBy int
+
+// Признаки группировки. Недели начинаются с понедельника, как у бюджетов.
+const (
+	GroupByAccount GroupBy = iota
+	GroupByCategory
+	GroupByStatus
+	GroupByDay
+	GroupByWeek
+	GroupByMonth
+)
+
+// AggregateOptions - параметры AggregatePayments.
+type AggregateOptions struct {
+	Where         PaymentPredicate // отбор платежей; нулевое значение - все платежи
+	IncludeFailed bool             // учитывать отклонённые платежи (по умолчанию - нет)
+	Location      *time.Location   // часовой пояс периодов; nil - пояс часов сервиса
+	Goroutines    int              // число горутин, как в SumPayments
+}
+
+// PaymentGroup - сумма и число платежей группы. Заполнено только поле,
+// по которому шла группировка: AccountID, Category, Status или Start.
+type PaymentGroup struct {
+	AccountID int64
+	Category  types.PaymentCategory
+	Status    types.PaymentStatus
+	Start     time.Time // начало дня, недели или месяца
+	Count     int
+	Total     types.Money
+	Fees      types.Money
+}
+
+// AggregatePayments - группирует платежи по groupBy и возвращает суммы и
+// число платежей групп, упорядоченные по признаку группировки. Отклонённые
+// платежи не учитываются, если не задано opts.IncludeFailed.
+func (s *Service) AggregatePayments(groupBy GroupBy, opts AggregateOptions) []PaymentGroup {
+	location := opts.Location
+	if location == nil {
+		location = s.now().Location()
+	}
+	goroutines := opts.Goroutines
+	if goroutines < 1 {
+		goroutines = 1
+	}
+
+	query := NewPaymentQuery(opts.Where)
+	candidates := query.candidates(s)
+
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	num := len(candidates)/goroutines + 1
+	groups := make(map[PaymentGroup]*PaymentGroup)
+
+	for i := 0; i < goroutines; i++ {
+		low := i * num
+		if low >= len(candidates) {
+			break
+		}
+		high := low + num
+		if high > len(candidates) {
+			high = len(candidates)
+		}
+
+		wg.Add(1)
+		go func(part []int) {
+			defer wg.Done()
+			local := make(map[PaymentGroup]*PaymentGroup)
+			for _, index := range part {
+				payment := s.payments[index]
+				if payment.Status == types.PaymentStatusFail && !opts.IncludeFailed {
+					continue
+				}
+				if !query.where.matches(payment) {
+					continue
+				}
+				key := groupKey(groupBy, payment, location)
+				group, ok := local[key]
+				if !ok {
+					group = &PaymentGroup{}
+					*group = key
+					local[key] = group
+				}
+				group.Count++
+				group.Total += payment.Amount
+				group.Fees += payment.Fee
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for key, group := range local {
+				total, ok := groups[key]
+				if !ok {
+					groups[key] = group
+					continue
+				}
+				total.Count += group.Count
+				total.Total += group.Total
+				total.Fees += group.Fees
+			}
+		}(candidates[low:high])
+	}
+
+	wg.Wait()
+
+	result := make([]PaymentGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		switch groupBy {
+		case GroupByAccount:
+			return a.AccountID < b.AccountID
+		case GroupByCategory:
+			return a.Category < b.Category
+		case GroupByStatus:
+			return a.Status < b.Status
+		default:
+			return a.Start.Before(b.Start)
+		}
+	})
+	return result
+}
+
+// groupKey возвращает группу платежа с заполненным признаком группировки.
+func groupKey(groupBy GroupBy, payment *types.Payment, location *time.Location) PaymentGroup {
+	switch groupBy {
+	case GroupByAccount:
+		return PaymentGroup{AccountID: payment.AccountID}
+	case GroupByCategory:
+		return PaymentGroup{Category: NormalizeCategory(payment.Category)}
+	case GroupByStatus:
+		return PaymentGroup{Status: payment.Status}
+	}
+
+	period := types.BudgetPeriodMonthly
+	switch groupBy {
+	case GroupByDay:
+		period = types.BudgetPeriodDaily
+	case GroupByWeek:
+		period = types.BudgetPeriodWeekly
+	}
+	start, _ := budgetPeriod(period, time.Unix(payment.Created, 0).In(location))
+	return PaymentGroup{Start: start}
+}
@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_AggregatePayments(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	first, second, err := s.addQueryPayments()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(s.payments[2].ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	byCategory := s.AggregatePayments(GroupByCategory, AggregateOptions{})
+	want := []PaymentGroup{
+		{Category: "auto", Count: 2, Total: 800_00},
+		{Category: "medicine", Count: 2, Total: 200_00},
+	}
+	if !reflect.DeepEqual(byCategory, want) {
+		t.Errorf("AggregatePayments(): by category = %v", byCategory)
+		return
+	}
+
+	byStatus := s.AggregatePayments(GroupByStatus, AggregateOptions{IncludeFailed: true})
+	want = []PaymentGroup{
+		{Status: types.PaymentStatusFail, Count: 1, Total: 200_00},
+		{Status: types.PaymentStatusInProgress, Count: 4, Total: 1_000_00},
+	}
+	if !reflect.DeepEqual(byStatus, want) {
+		t.Errorf("AggregatePayments(): by status = %v", byStatus)
+		return
+	}
+
+	for _, goroutines := range []int{0, 1, 2, 10} {
+		byAccount := s.AggregatePayments(GroupByAccount, AggregateOptions{Goroutines: goroutines})
+		want = []PaymentGroup{
+			{AccountID: first.ID, Count: 2, Total: 400_00},
+			{AccountID: second.ID, Count: 2, Total: 600_00},
+		}
+		if !reflect.DeepEqual(byAccount, want) {
+			t.Errorf("AggregatePayments(): goroutines = %v, by account = %v", goroutines, byAccount)
+			return
+		}
+	}
+
+	filtered := s.AggregatePayments(GroupByAccount, AggregateOptions{Where: ByAccount(second.ID)})
+	if len(filtered) != 1 || filtered[0].AccountID != second.ID {
+		t.Errorf("AggregatePayments(): filtered = %v", filtered)
+		return
+	}
+}
+
+func TestService_AggregatePayments_periods(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, 2, 28, 12, 0, 0, 0, time.UTC) // среда
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, day := range []int{0, 0, 1, 5, 6} {
+		now = time.Date(2024, 2, 28+day, 12, 0, 0, 0, time.UTC)
+		_, err = s.Pay(account.ID, 10_00, "auto")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	days := s.AggregatePayments(GroupByDay, AggregateOptions{})
+	if len(days) != 4 || days[0].Count != 2 || !days[0].Start.Equal(time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("AggregatePayments(): by day = %v", days)
+		return
+	}
+
+	weeks := s.AggregatePayments(GroupByWeek, AggregateOptions{})
+	want := []PaymentGroup{
+		{Start: time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), Count: 3, Total: 30_00},
+		{Start: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), Count: 2, Total: 20_00},
+	}
+	if !reflect.DeepEqual(weeks, want) {
+		t.Errorf("AggregatePayments(): by week = %v", weeks)
+		return
+	}
+
+	months := s.AggregatePayments(GroupByMonth, AggregateOptions{})
+	want = []PaymentGroup{
+		{Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Count: 3, Total: 30_00},
+		{Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Count: 2, Total: 20_00},
+	}
+	if !reflect.DeepEqual(months, want) {
+		t.Errorf("AggregatePayments(): by month = %v", months)
+		return
+	}
+}
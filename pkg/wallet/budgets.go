@@ -0,0 +1,195 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrBudgetNotFound = errors.New("budget not found")
+var ErrInvalidBudgetPeriod = errors.New("invalid budget period")
+
+// Пороги (в процентах), при достижении которых отправляется предупреждение.
+var budgetThresholds = []int{80, 100}
+
+// BudgetAlert - событие о достижении порога бюджета.
+type BudgetAlert struct {
+	Budget      types.Budget
+	Threshold   int // 80 или 100 процентов
+	Spent       types.Money
+	PeriodStart time.Time
+}
+
+// BudgetStatus - текущее состояние бюджета за период.
+type BudgetStatus struct {
+	Budget      types.Budget
+	Spent       types.Money
+	Remaining   types.Money
+	Percent     int
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+// budgetMark хранит последний отправленный порог бюджета в пределах периода.
+type budgetMark struct {
+	periodStart int64
+	threshold   int
+}
+
+// SetBudget - задаёт бюджет аккаунта на категорию и период.
+// Если такой бюджет уже есть, меняется только его сумма.
+func (s *Service) SetBudget(accountID int64, category types.PaymentCategory, period types.BudgetPeriod, amount types.Money) (*types.Budget, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	switch period {
+	case types.BudgetPeriodDaily, types.BudgetPeriodWeekly, types.BudgetPeriodMonthly:
+	default:
+		return nil, ErrInvalidBudgetPeriod
+	}
+
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, budget := range s.budgets {
+		if budget.AccountID == accountID && budget.Category == category && budget.Period == period {
+			budget.Amount = amount
+			delete(s.budgetMarks, budget.ID)
+			return budget, nil
+		}
+	}
+
+	budget := &types.Budget{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Category:  category,
+		Period:    period,
+		Amount:    amount,
+	}
+	s.budgets = append(s.budgets, budget)
+	return budget, nil
+}
+
+// FindBudgetByID - поиск бюджета по идентификатору.
+func (s *Service) FindBudgetByID(budgetID string) (*types.Budget, error) {
+	for _, budget := range s.budgets {
+		if budget.ID == budgetID {
+			return budget, nil
+		}
+	}
+
+	return nil, ErrBudgetNotFound
+}
+
+// RemoveBudget - удаляет бюджет.
+func (s *Service) RemoveBudget(budgetID string) error {
+	for i, budget := range s.budgets {
+		if budget.ID == budgetID {
+			s.budgets = append(s.budgets[:i], s.budgets[i+1:]...)
+			delete(s.budgetMarks, budgetID)
+			return nil
+		}
+	}
+
+	return ErrBudgetNotFound
+}
+
+// OnBudgetAlert - подписывает обработчик на предупреждения о бюджете.
+func (s *Service) OnBudgetAlert(handler func(alert BudgetAlert)) {
+	s.budgetHandlers = append(s.budgetHandlers, handler)
+}
+
+// BudgetStatuses - возвращает состояние всех бюджетов аккаунта за текущий период.
+func (s *Service) BudgetStatuses(accountID int64) ([]BudgetStatus, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []BudgetStatus{}
+	for _, budget := range s.budgets {
+		if budget.AccountID == accountID {
+			statuses = append(statuses, s.budgetStatus(budget))
+		}
+	}
+
+	return statuses, nil
+}
+
+// budgetStatus считает потраченную сумму бюджета за текущий период.
+// Отклонённые платежи не учитываются.
+func (s *Service) budgetStatus(budget *types.Budget) BudgetStatus {
+	start, end := budgetPeriod(budget.Period, s.now())
+	spent := s.spentSince(limitKey{budget.AccountID, budget.Category}, start)
+
+	return BudgetStatus{
+		Budget:      *budget,
+		Spent:       spent,
+		Remaining:   remaining(budget.Amount, spent),
+		Percent:     int(spent * 100 / budget.Amount),
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+}
+
+// checkBudgets отправляет предупреждения по бюджетам, затронутым платежом.
+func (s *Service) checkBudgets(payment *types.Payment) {
+	for _, budget := range s.budgets {
+		if budget.AccountID != payment.AccountID {
+			continue
+		}
+		if budget.Category != "" && budget.Category != payment.Category {
+			continue
+		}
+
+		status := s.budgetStatus(budget)
+		if s.budgetMarks == nil {
+			s.budgetMarks = make(map[string]budgetMark)
+		}
+		mark := s.budgetMarks[budget.ID]
+		if mark.periodStart != status.PeriodStart.Unix() {
+			mark = budgetMark{periodStart: status.PeriodStart.Unix()}
+		}
+
+		for _, threshold := range budgetThresholds {
+			if status.Percent < threshold || mark.threshold >= threshold {
+				continue
+			}
+			mark.threshold = threshold
+
+			alert := BudgetAlert{
+				Budget:      *budget,
+				Threshold:   threshold,
+				Spent:       status.Spent,
+				PeriodStart: status.PeriodStart,
+			}
+			for _, handler := range s.budgetHandlers {
+				handler(alert)
+			}
+		}
+		s.budgetMarks[budget.ID] = mark
+	}
+}
+
+// budgetPeriod возвращает границы периода, в который попадает момент now.
+// Неделя начинается с понедельника.
+func budgetPeriod(period types.BudgetPeriod, now time.Time) (time.Time, time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch period {
+	case types.BudgetPeriodDaily:
+		return day, day.AddDate(0, 0, 1)
+	case types.BudgetPeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	default:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+}
@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_SetBudget_alerts(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	budget, err := s.SetBudget(account.ID, "restaurant", types.BudgetPeriodMonthly, 500_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	alerts := []BudgetAlert{}
+	s.OnBudgetAlert(func(alert BudgetAlert) {
+		alerts = append(alerts, alert)
+	})
+
+	_, err = s.Pay(account.ID, 300_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(alerts) != 0 {
+		t.Errorf("Pay(): unexpected alerts = %v", alerts)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 150_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(alerts) != 1 || alerts[0].Threshold != 80 || alerts[0].Budget.ID != budget.ID {
+		t.Errorf("Pay(): must emit 80%% alert, alerts = %v", alerts)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(alerts) != 2 || alerts[1].Threshold != 100 {
+		t.Errorf("Pay(): must emit 100%% alert, alerts = %v", alerts)
+		return
+	}
+
+	// в новом месяце бюджет начинается заново
+	now = now.AddDate(0, 1, 0)
+	_, err = s.Pay(account.ID, 450_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(alerts) != 3 || alerts[2].Threshold != 80 {
+		t.Errorf("Pay(): must emit 80%% alert in new period, alerts = %v", alerts)
+		return
+	}
+}
+
+func TestService_BudgetStatuses_rejected(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SetBudget(account.ID, "restaurant", types.BudgetPeriodWeekly, 500_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 200_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	statuses, err := s.BudgetStatuses(account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(statuses) != 1 {
+		t.Errorf("BudgetStatuses(): wrong count = %v", len(statuses))
+		return
+	}
+	if statuses[0].Spent != 100_00 || statuses[0].Remaining != 400_00 || statuses[0].Percent != 20 {
+		t.Errorf("BudgetStatuses(): wrong status = %v", statuses[0])
+		return
+	}
+}
+
+func TestService_SetBudget_invalidPeriod(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SetBudget(account.ID, "restaurant", "YEARLY", 500_00)
+	if err != ErrInvalidBudgetPeriod {
+		t.Errorf("SetBudget(): must return ErrInvalidBudgetPeriod, returned: %v", err)
+		return
+	}
+}
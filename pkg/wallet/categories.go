@@ -0,0 +1,257 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrCategoryNotFound = errors.New("category not found")
+var ErrCategoryInactive = errors.New("category is not active")
+var ErrCategoryExists = errors.New("category already exists")
+var ErrCategoryCodeEmpty = errors.New("category code is empty")
+var ErrInvalidCategoryCode = errors.New("invalid category code")
+
+// NormalizeCategory - приводит код категории к каноническому виду
+// (нижний регистр, без пробелов по краям), чтобы "Auto" и "auto" совпадали.
+func NormalizeCategory(code types.PaymentCategory) types.PaymentCategory {
+	return types.PaymentCategory(strings.ToLower(strings.TrimSpace(string(code))))
+}
+
+// RegisterCategory - добавляет категорию в справочник. Если справочник не пуст,
+// Pay принимает только активные категории из него.
+func (s *Service) RegisterCategory(code types.PaymentCategory, name string, parent types.PaymentCategory) (*types.Category, error) {
+	code = NormalizeCategory(code)
+	parent = NormalizeCategory(parent)
+	if code == "" {
+		return nil, ErrCategoryCodeEmpty
+	}
+	// код пишется в дампы как есть, поэтому разделители в нём запрещены
+	if strings.ContainsAny(string(code), ";|\\") || strings.IndexFunc(string(code), unicode.IsControl) >= 0 {
+		return nil, ErrInvalidCategoryCode
+	}
+
+	_, err := s.FindCategory(code)
+	if err == nil {
+		return nil, ErrCategoryExists
+	}
+
+	if parent != "" {
+		_, err = s.FindCategory(parent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	category := &types.Category{
+		Code:   code,
+		Name:   name,
+		Parent: parent,
+		Active: true,
+	}
+	s.categories = append(s.categories, category)
+	return category, nil
+}
+
+// FindCategory - поиск категории по коду.
+func (s *Service) FindCategory(code types.PaymentCategory) (*types.Category, error) {
+	code = NormalizeCategory(code)
+	for _, category := range s.categories {
+		if category.Code == code {
+			return category, nil
+		}
+	}
+
+	return nil, ErrCategoryNotFound
+}
+
+// SetCategoryActive - включает или отключает категорию.
+func (s *Service) SetCategoryActive(code types.PaymentCategory, active bool) error {
+	category, err := s.FindCategory(code)
+	if err != nil {
+		return err
+	}
+
+	category.Active = active
+	return nil
+}
+
+// Categories - возвращает справочник категорий, отсортированный по коду.
+func (s *Service) Categories() []types.Category {
+	categories := []types.Category{}
+	for _, category := range s.categories {
+		categories = append(categories, *category)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Code < categories[j].Code
+	})
+	return categories
+}
+
+// IsSubcategory - проверяет, что категория code совпадает с parent или вложена в неё.
+func (s *Service) IsSubcategory(code types.PaymentCategory, parent types.PaymentCategory) bool {
+	parent = NormalizeCategory(parent)
+	for _, ancestor := range s.categoryPath(code) {
+		if ancestor == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryPath возвращает код категории и коды всех её родительских категорий,
+// начиная с ближайшей.
+func (s *Service) categoryPath(code types.PaymentCategory) []types.PaymentCategory {
+	code = NormalizeCategory(code)
+	path := []types.PaymentCategory{}
+
+	// ограничиваем глубину на случай повреждённого справочника
+	for depth := 0; depth <= len(s.categories) && code != ""; depth++ {
+		path = append(path, code)
+		category, err := s.FindCategory(code)
+		if err != nil {
+			break
+		}
+		code = category.Parent
+	}
+	return path
+}
+
+// CategoryTotals - суммирует неотклонённые платежи по категориям справочника,
+// включая в сумму родительской категории платежи всех вложенных.
+func (s *Service) CategoryTotals() map[types.PaymentCategory]types.Money {
+	totals := make(map[types.PaymentCategory]types.Money)
+	for _, payment := range s.payments {
+		if payment.Status == types.PaymentStatusFail {
+			continue
+		}
+		for _, category := range s.categories {
+			if s.IsSubcategory(payment.Category, category.Code) {
+				totals[category.Code] += payment.Amount
+			}
+		}
+	}
+	return totals
+}
+
+// validateCategory проверяет категорию платежа по справочнику и возвращает
+// её канонический код. Пока справочник пуст, принимается любая категория.
+func (s *Service) validateCategory(code types.PaymentCategory) (types.PaymentCategory, error) {
+	if len(s.categories) == 0 {
+		return code, nil
+	}
+
+	category, err := s.FindCategory(code)
+	if err != nil {
+		return "", err
+	}
+	if !category.Active {
+		return "", ErrCategoryInactive
+	}
+	return category.Code, nil
+}
+
+// CategoryFilter - настраиваемый фильтр платежей по категориям для FilterPaymentsByFn.
+type CategoryFilter struct {
+	categories []types.PaymentCategory
+	catalog    *Service
+}
+
+// NewCategoryFilter - создаёт фильтр по перечисленным категориям.
+func NewCategoryFilter(categories ...types.PaymentCategory) *CategoryFilter {
+	filter := &CategoryFilter{}
+	return filter.With(categories...)
+}
+
+// With - добавляет категории в фильтр.
+func (f *CategoryFilter) With(categories ...types.PaymentCategory) *CategoryFilter {
+	for _, category := range categories {
+		f.categories = append(f.categories, NormalizeCategory(category))
+	}
+	return f
+}
+
+// WithSubcategories - включает в фильтр вложенные категории по справочнику сервиса.
+func (f *CategoryFilter) WithSubcategories(s *Service) *CategoryFilter {
+	f.catalog = s
+	return f
+}
+
+// Build - возвращает функцию фильтрации.
+func (f *CategoryFilter) Build() func(payment types.Payment) bool {
+	categories := append([]types.PaymentCategory{}, f.categories...)
+	catalog := f.catalog
+
+	return func(payment types.Payment) bool {
+		code := NormalizeCategory(payment.Category)
+		for _, category := range categories {
+			if code == category {
+				return true
+			}
+			if catalog != nil && catalog.IsSubcategory(code, category) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// exportCategories сохраняет справочник категорий в dir/categories.dump.
+func (s *Service) exportCategories(dir string) error {
+	if s.categories == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, category := range s.categories {
+		text := []byte(
+			string(category.Code) + ";" +
+				escapeField(category.Name) + ";" +
+				string(category.Parent) + ";" +
+				strconv.FormatBool(category.Active) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "categories.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importCategories загружает справочник категорий из dir/categories.dump, если файл есть.
+func (s *Service) importCategories(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "categories.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		active, _ := strconv.ParseBool(fields[3])
+
+		category, err := s.FindCategory(types.PaymentCategory(fields[0]))
+		if err != nil {
+			category = &types.Category{Code: NormalizeCategory(types.PaymentCategory(fields[0]))}
+			s.categories = append(s.categories, category)
+		}
+		category.Name = fields[1]
+		category.Parent = types.PaymentCategory(fields[2])
+		category.Active = active
+	}
+}
@@ -0,0 +1,147 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func (s *testService) addCategories() error {
+	categories := []struct {
+		code   types.PaymentCategory
+		name   string
+		parent types.PaymentCategory
+	}{
+		{code: "auto", name: "Авто"},
+		{code: "fuel", name: "Топливо", parent: "auto"},
+		{code: "car-wash", name: "Автомойка", parent: "auto"},
+		{code: "medicine", name: "Аптеки"},
+	}
+
+	for _, category := range categories {
+		_, err := s.RegisterCategory(category.code, category.name, category.parent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestService_Pay_categoryValidation(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100_00, " Auto ")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	if payment.Category != "auto" {
+		t.Errorf("Pay(): category must be normalized, payment = %v", payment)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100_00, "car")
+	if err != ErrCategoryNotFound {
+		t.Errorf("Pay(): must return ErrCategoryNotFound, returned: %v", err)
+		return
+	}
+
+	err = s.SetCategoryActive("medicine", false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100_00, "medicine")
+	if err != ErrCategoryInactive {
+		t.Errorf("Pay(): must return ErrCategoryInactive, returned: %v", err)
+		return
+	}
+}
+
+func TestService_CategoryTotals_hierarchy(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, category := range []types.PaymentCategory{"fuel", "car-wash", "auto", "medicine"} {
+		_, err = s.Pay(account.ID, 100_00, category)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	totals := s.CategoryTotals()
+	if totals["auto"] != 300_00 || totals["fuel"] != 100_00 || totals["medicine"] != 100_00 {
+		t.Errorf("CategoryTotals(): wrong totals = %v", totals)
+		return
+	}
+
+	payments, err := s.FilterPaymentsByFn(NewCategoryFilter("auto").WithSubcategories(s.Service).Build(), 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(payments) != 3 {
+		t.Errorf("FilterPaymentsByFn(): wrong payments = %v", payments)
+		return
+	}
+
+	payments, err = s.FilterPaymentsByFn(NewCategoryFilter("FUEL", "medicine").Build(), 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(payments) != 2 {
+		t.Errorf("FilterPaymentsByFn(): wrong payments = %v", payments)
+		return
+	}
+}
+
+func TestService_RegisterCategory_fail(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.RegisterCategory("AUTO", "Машины", "")
+	if err != ErrCategoryExists {
+		t.Errorf("RegisterCategory(): must return ErrCategoryExists, returned: %v", err)
+		return
+	}
+
+	_, err = s.RegisterCategory("taxi", "Такси", "transport")
+	if err != ErrCategoryNotFound {
+		t.Errorf("RegisterCategory(): must return ErrCategoryNotFound, returned: %v", err)
+		return
+	}
+
+	for _, code := range []types.PaymentCategory{"taxi;1", "taxi\nbus", "a|b", `a\b`} {
+		_, err = s.RegisterCategory(code, "Такси", "")
+		if err != ErrInvalidCategoryCode {
+			t.Errorf("RegisterCategory(%q): must return ErrInvalidCategoryCode, returned: %v", code, err)
+			return
+		}
+	}
+}
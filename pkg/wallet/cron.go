@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// cronSchedule - разобранное cron-выражение из пяти полей:
+// минуты, часы, день месяца, месяц, день недели.
+type cronSchedule struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	anyDay   bool
+	anyWeek  bool
+}
+
+// parseCron разбирает выражение вида "30 9 1 * *".
+// Поддерживаются "*", числа, списки "1,15", диапазоны "1-5" и шаги "*/10".
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, ErrInvalidCron
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// 7 - тоже воскресенье
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &cronSchedule{
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+		anyDay:   fields[2] == "*",
+		anyWeek:  fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			value, err := strconv.Atoi(part[i+1:])
+			if err != nil || value < 1 {
+				return nil, ErrInvalidCron
+			}
+			step = value
+			part = part[:i]
+		}
+
+		low, high := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			value, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, ErrInvalidCron
+			}
+			low, high = value, value
+			if len(bounds) == 2 {
+				high, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, ErrInvalidCron
+				}
+			} else if step > 1 {
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, ErrInvalidCron
+		}
+
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+// matchDay проверяет день месяца и день недели. Как и в cron, если ограничены
+// оба поля, достаточно совпадения любого из них.
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	day := c.days[t.Day()]
+	week := c.weekdays[int(t.Weekday())]
+
+	switch {
+	case c.anyDay && c.anyWeek:
+		return true
+	case c.anyDay:
+		return week
+	case c.anyWeek:
+		return day
+	default:
+		return day || week
+	}
+}
+
+// matches проверяет, подходит ли под выражение момент t. Момент должен
+// приходиться ровно на начало минуты.
+func (c *cronSchedule) matches(t time.Time) bool {
+	if !t.Equal(t.Truncate(time.Minute)) {
+		return false
+	}
+	return c.months[int(t.Month())] && c.matchDay(t) && c.hours[t.Hour()] && c.minutes[t.Minute()]
+}
+
+// next возвращает первый момент строго после after, подходящий под выражение.
+func (c *cronSchedule) next(after time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !c.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+
+	return time.Time{}, false
+}
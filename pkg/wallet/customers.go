@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrCustomerNotFound = errors.New("customer not found")
+var ErrAccountNameTaken = errors.New("customer already has account with this name")
+var ErrAccountNotOwned = errors.New("account does not belong to customer")
+var ErrAccountNameEmpty = errors.New("account name is empty")
+
+// DefaultAccountName - название основного счёта, создаваемого при регистрации.
+const DefaultAccountName = "main"
+
+// FindCustomerByID - поиск клиента по идентификатору.
+func (s *Service) FindCustomerByID(customerID int64) (*types.Customer, error) {
+	for _, customer := range s.customers {
+		if customer.ID == customerID {
+			return customer, nil
+		}
+	}
+
+	return nil, ErrCustomerNotFound
+}
+
+// OpenAccount - открывает клиенту дополнительный счёт (кошелёк) с названием name.
+func (s *Service) OpenAccount(customerID int64, name string) (*types.Account, error) {
+	customer, err := s.FindCustomerByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrAccountNameEmpty
+	}
+
+	for _, account := range s.accounts {
+		if account.CustomerID == customerID && strings.EqualFold(account.Name, name) {
+			return nil, ErrAccountNameTaken
+		}
+	}
+
+	return s.newAccount(customer, name), nil
+}
+
+// CustomerAccounts - возвращает все счета клиента.
+func (s *Service) CustomerAccounts(customerID int64) ([]types.Account, error) {
+	_, err := s.FindCustomerByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []types.Account{}
+	for _, account := range s.accounts {
+		if account.CustomerID == customerID {
+			accounts = append(accounts, *account)
+		}
+	}
+	return accounts, nil
+}
+
+// MoveBetweenAccounts - переводит деньги между собственными счетами клиента.
+func (s *Service) MoveBetweenAccounts(customerID int64, fromAccountID int64, toAccountID int64, amount types.Money) error {
+	_, err := s.FindCustomerByID(customerID)
+	if err != nil {
+		return err
+	}
+
+	for _, accountID := range []int64{fromAccountID, toAccountID} {
+		account, err := s.FindAccountByID(accountID)
+		if err != nil {
+			return err
+		}
+		if account.CustomerID != customerID {
+			return ErrAccountNotOwned
+		}
+	}
+
+	return s.Transfer(fromAccountID, toAccountID, amount)
+}
+
+// newCustomer регистрирует клиента вместе с основным счётом.
+func (s *Service) newCustomer(phone types.Phone) (*types.Customer, *types.Account) {
+	s.nextCustomerID++
+	customer := &types.Customer{
+		ID:    s.nextCustomerID,
+		Phone: phone,
+	}
+	s.customers = append(s.customers, customer)
+
+	account := s.newAccount(customer, DefaultAccountName)
+	customer.DefaultAccountID = account.ID
+	return customer, account
+}
+
+// newAccount создаёт клиенту новый счёт.
+func (s *Service) newAccount(customer *types.Customer, name string) *types.Account {
+	s.nextAccountID++
+	account := &types.Account{
+		ID:         s.nextAccountID,
+		Phone:      customer.Phone,
+		Balance:    0,
+		Status:     types.AccountStatusActive,
+		CustomerID: customer.ID,
+		Name:       name,
+	}
+	s.accounts = append(s.accounts, account)
+	return account
+}
+
+// linkCustomers создаёт клиентов для счетов, загруженных из дампов без
+// информации о владельце: раньше один номер означал один счёт, поэтому каждый
+// такой счёт становится основным счётом отдельного клиента. Совпадающие после
+// нормализации номера можно найти через CheckPhones.
+func (s *Service) linkCustomers() {
+	for _, account := range s.accounts {
+		if account.ID > s.nextAccountID {
+			s.nextAccountID = account.ID
+		}
+		if account.CustomerID != 0 {
+			continue
+		}
+
+		s.nextCustomerID++
+		customer := &types.Customer{
+			ID:               s.nextCustomerID,
+			Phone:            account.Phone,
+			DefaultAccountID: account.ID,
+		}
+		s.customers = append(s.customers, customer)
+
+		account.CustomerID = customer.ID
+		if account.Name == "" {
+			account.Name = DefaultAccountName
+		}
+	}
+}
+
+// exportCustomers сохраняет клиентов в dir/customers.dump.
+func (s *Service) exportCustomers(dir string) error {
+	if s.customers == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, customer := range s.customers {
+		text := []byte(
+			strconv.FormatInt(customer.ID, 10) + ";" +
+				escapeField(string(customer.Phone)) + ";" +
+				strconv.FormatInt(customer.DefaultAccountID, 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "customers.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importCustomers загружает клиентов из dir/customers.dump, если файл есть.
+func (s *Service) importCustomers(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "customers.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 3 {
+			continue
+		}
+
+		id, _ := strconv.ParseInt(fields[0], 10, 64)
+		defaultAccountID, _ := strconv.ParseInt(fields[2], 10, 64)
+
+		customer, err := s.FindCustomerByID(id)
+		if err != nil {
+			customer = &types.Customer{ID: id}
+			s.customers = append(s.customers, customer)
+		}
+		customer.Phone = normalizeOrRaw(types.Phone(fields[1]))
+		customer.DefaultAccountID = defaultAccountID
+
+		if id > s.nextCustomerID {
+			s.nextCustomerID = id
+		}
+	}
+}
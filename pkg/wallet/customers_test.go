@@ -0,0 +1,185 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_OpenAccount(t *testing.T) {
+	s := newTestService()
+	mainAccount, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if mainAccount.CustomerID == 0 || mainAccount.Name != DefaultAccountName {
+		t.Errorf("RegisterAccount(): account must belong to customer, account = %v", mainAccount)
+		return
+	}
+
+	savings, err := s.OpenAccount(mainAccount.CustomerID, "savings")
+	if err != nil {
+		t.Errorf("OpenAccount(): error = %v", err)
+		return
+	}
+	if savings.Phone != mainAccount.Phone || savings.ID == mainAccount.ID {
+		t.Errorf("OpenAccount(): wrong account = %v", savings)
+		return
+	}
+
+	_, err = s.OpenAccount(mainAccount.CustomerID, "Savings")
+	if err != ErrAccountNameTaken {
+		t.Errorf("OpenAccount(): must return ErrAccountNameTaken, returned: %v", err)
+		return
+	}
+
+	accounts, err := s.CustomerAccounts(mainAccount.CustomerID)
+	if err != nil || len(accounts) != 2 {
+		t.Errorf("CustomerAccounts(): wrong accounts = %v, error = %v", accounts, err)
+		return
+	}
+
+	// поиск по номеру возвращает основной счёт
+	found, err := s.FindAccountByPhone(mainAccount.Phone)
+	if err != nil || found.ID != mainAccount.ID {
+		t.Errorf("FindAccountByPhone(): must return main account, returned = %v, error = %v", found, err)
+		return
+	}
+
+	_, err = s.RegisterAccount(mainAccount.Phone)
+	if err != ErrPhoneRegistered {
+		t.Errorf("RegisterAccount(): must return ErrPhoneRegistered, returned: %v", err)
+		return
+	}
+
+	report := s.CheckPhones()
+	if len(report.Duplicates) != 0 {
+		t.Errorf("CheckPhones(): wallets of one customer are not duplicates, report = %v", report)
+		return
+	}
+}
+
+func TestService_MoveBetweenAccounts(t *testing.T) {
+	s := newTestService()
+	mainAccount, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := s.addAccountWithBalance("+992000000002", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	travel, err := s.OpenAccount(mainAccount.CustomerID, "travel")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.MoveBetweenAccounts(mainAccount.CustomerID, mainAccount.ID, travel.ID, 300_00)
+	if err != nil {
+		t.Errorf("MoveBetweenAccounts(): error = %v", err)
+		return
+	}
+	if mainAccount.Balance != 700_00 || travel.Balance != 300_00 {
+		t.Errorf("MoveBetweenAccounts(): wrong balances, main = %v, travel = %v", mainAccount, travel)
+		return
+	}
+
+	err = s.MoveBetweenAccounts(mainAccount.CustomerID, mainAccount.ID, other.ID, 100)
+	if err != ErrAccountNotOwned {
+		t.Errorf("MoveBetweenAccounts(): must return ErrAccountNotOwned, returned: %v", err)
+		return
+	}
+
+	err = s.ChangePhone(travel.ID, "+992000000009")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if mainAccount.Phone != "+992000000009" {
+		t.Errorf("ChangePhone(): all customer accounts must change phone, main = %v", mainAccount)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	accounts, err := imported.CustomerAccounts(mainAccount.CustomerID)
+	if err != nil || len(accounts) != 2 {
+		t.Errorf("Import(): wrong customer accounts = %v, error = %v", accounts, err)
+		return
+	}
+
+	opened, err := imported.OpenAccount(mainAccount.CustomerID, "savings")
+	if err != nil || opened.ID <= travel.ID {
+		t.Errorf("OpenAccount(): wrong account after import = %v, error = %v", opened, err)
+		return
+	}
+}
+
+func TestService_MoveBetweenAccounts_noFee(t *testing.T) {
+	s := newTestService()
+	mainAccount, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000003")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.AddFeeRule(FeeRule{Channel: types.ChannelTransfer, Flat: 1_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	travel, err := s.OpenAccount(mainAccount.CustomerID, "travel")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.MoveBetweenAccounts(mainAccount.CustomerID, mainAccount.ID, travel.ID, 300_00)
+	if err != nil {
+		t.Errorf("MoveBetweenAccounts(): error = %v", err)
+		return
+	}
+	if mainAccount.Balance != 700_00 || revenue.Balance != 0 {
+		t.Errorf("MoveBetweenAccounts(): fee must not be charged, main = %v, revenue = %v", mainAccount, revenue)
+		return
+	}
+
+	err = s.Transfer(mainAccount.ID, other.ID, 100_00)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+	if mainAccount.Balance != 599_00 || revenue.Balance != 1_00 {
+		t.Errorf("Transfer(): fee must be charged, main = %v, revenue = %v", mainAccount, revenue)
+		return
+	}
+}
@@ -0,0 +1,132 @@
+package wallet
+
+import (
+	"sort"
+	"strings"
+)
+
+var dumpEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, "\n", `\n`, "|", `\|`)
+
+// escapeField экранирует произвольный текст для записи в поле дампа,
+// чтобы разделители ";", "|" и перевод строки не ломали формат.
+func escapeField(value string) string {
+	return dumpEscaper.Replace(value)
+}
+
+// splitFields разбивает строку дампа на поля по неэкранированным ";"
+// и снимает экранирование с каждого поля.
+func splitFields(line string) []string {
+	fields := []string{}
+	field := strings.Builder{}
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			if r == 'n' {
+				field.WriteRune('\n')
+			} else {
+				field.WriteRune(r)
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+
+	return append(fields, field.String())
+}
+
+var listEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`)
+
+// joinList записывает список значений в одно поле дампа через ",".
+func joinList(values []string) string {
+	escaped := make([]string, 0, len(values))
+	for _, value := range values {
+		escaped = append(escaped, listEscaper.Replace(value))
+	}
+	return escapeField(strings.Join(escaped, ","))
+}
+
+// splitList разбирает поле, записанное joinList (уже разобранное splitFields).
+func splitList(field string) []string {
+	if field == "" {
+		return nil
+	}
+
+	values := []string{}
+	value := strings.Builder{}
+	escaped := false
+	for _, r := range field {
+		switch {
+		case escaped:
+			value.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			values = append(values, value.String())
+			value.Reset()
+		default:
+			value.WriteRune(r)
+		}
+	}
+	return append(values, value.String())
+}
+
+// joinMap записывает пары "ключ=значение" в одно поле дампа через ",",
+// упорядочивая их по ключу.
+func joinMap(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, listEscaper.Replace(key)+"="+listEscaper.Replace(values[key]))
+	}
+	return escapeField(strings.Join(pairs, ","))
+}
+
+// splitMap разбирает поле, записанное joinMap (уже разобранное splitFields).
+func splitMap(field string) map[string]string {
+	if field == "" {
+		return nil
+	}
+
+	values := make(map[string]string)
+	key, value := strings.Builder{}, strings.Builder{}
+	current := &key
+	escaped := false
+	commit := func() {
+		values[key.String()] = value.String()
+		key.Reset()
+		value.Reset()
+		current = &key
+	}
+
+	for _, r := range field {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '=' && current == &key:
+			current = &value
+		case r == ',':
+			commit()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	commit()
+	return values
+}
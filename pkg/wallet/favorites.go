@@ -0,0 +1,203 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrFavoriteExists = errors.New("favorite with this name already exists")
+var ErrFavoriteNameEmpty = errors.New("favorite name is empty")
+
+// CreateFavorite - создаёт избранное без исходного платежа.
+func (s *Service) CreateFavorite(accountID int64, name string, category types.PaymentCategory, amount types.Money, payee string) (*types.Favorite, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkFavoriteName(accountID, name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	favorite := &types.Favorite{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Name:      name,
+		Amount:    amount,
+		Category:  category,
+		Position:  s.countFavorites(accountID),
+		Payee:     payee,
+	}
+	s.favorites = append(s.favorites, favorite)
+	return favorite, nil
+}
+
+// FavoritePayments - возвращает платежи, совершённые из избранного.
+func (s *Service) FavoritePayments(favoriteID string) []types.Payment {
+	payments := []types.Payment{}
+	for _, payment := range s.payments {
+		if payment.FavoriteID == favoriteID {
+			payments = append(payments, *payment)
+		}
+	}
+	return payments
+}
+
+// UpdateFavorite - изменяет название, сумму и категорию избранного.
+func (s *Service) UpdateFavorite(favoriteID string, name string, amount types.Money, category types.PaymentCategory) (*types.Favorite, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkFavoriteName(favorite.AccountID, name, favorite.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	favorite.Name = name
+	favorite.Amount = amount
+	favorite.Category = category
+	return favorite, nil
+}
+
+// RenameFavorite - переименовывает избранное.
+func (s *Service) RenameFavorite(favoriteID string, name string) (*types.Favorite, error) {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpdateFavorite(favoriteID, name, favorite.Amount, favorite.Category)
+}
+
+// DeleteFavorite - удаляет избранное и отключает его расписания.
+func (s *Service) DeleteFavorite(favoriteID string) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	for i, fav := range s.favorites {
+		if fav.ID == favoriteID {
+			s.favorites = append(s.favorites[:i], s.favorites[i+1:]...)
+			break
+		}
+	}
+
+	for _, schedule := range s.schedules {
+		if schedule.FavoriteID == favoriteID {
+			schedule.Active = false
+		}
+	}
+
+	s.renumberFavorites(favorite.AccountID)
+	return nil
+}
+
+// MoveFavorite - перемещает избранное на позицию position в списке аккаунта.
+// Позиция за пределами списка означает его начало или конец.
+func (s *Service) MoveFavorite(favoriteID string, position int) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	favorites := s.accountFavorites(favorite.AccountID)
+	if position < 0 {
+		position = 0
+	}
+	if position > len(favorites)-1 {
+		position = len(favorites) - 1
+	}
+
+	for i, fav := range favorites {
+		if fav.ID == favoriteID {
+			favorites = append(favorites[:i], favorites[i+1:]...)
+			break
+		}
+	}
+	favorites = append(favorites[:position], append([]*types.Favorite{favorite}, favorites[position:]...)...)
+
+	for i, fav := range favorites {
+		fav.Position = i
+	}
+	return nil
+}
+
+// FavoritesByAccount - возвращает избранное аккаунта в порядке позиций.
+func (s *Service) FavoritesByAccount(accountID int64) ([]types.Favorite, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites := []types.Favorite{}
+	for _, favorite := range s.accountFavorites(accountID) {
+		favorites = append(favorites, *favorite)
+	}
+	return favorites, nil
+}
+
+// checkFavoriteName проверяет, что у аккаунта нет другого избранного с таким же
+// названием (без учёта регистра и пробелов по краям). exceptID - избранное,
+// которое не учитывается при проверке.
+func (s *Service) checkFavoriteName(accountID int64, name string, exceptID string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrFavoriteNameEmpty
+	}
+
+	for _, favorite := range s.favorites {
+		if favorite.AccountID != accountID || favorite.ID == exceptID {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(favorite.Name), strings.TrimSpace(name)) {
+			return ErrFavoriteExists
+		}
+	}
+	return nil
+}
+
+// accountFavorites возвращает избранное аккаунта, отсортированное по позиции.
+func (s *Service) accountFavorites(accountID int64) []*types.Favorite {
+	favorites := []*types.Favorite{}
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID {
+			favorites = append(favorites, favorite)
+		}
+	}
+
+	sort.SliceStable(favorites, func(i, j int) bool {
+		return favorites[i].Position < favorites[j].Position
+	})
+	return favorites
+}
+
+func (s *Service) countFavorites(accountID int64) int {
+	count := 0
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Service) renumberFavorites(accountID int64) {
+	for i, favorite := range s.accountFavorites(accountID) {
+		favorite.Position = i
+	}
+}
@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestService_FavoritePayment_duplicate(t *testing.T) {
+	s := newTestService()
+	_, payments, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.FavoritePayment(payments[0].ID, " favorite PAYMENT_0 ")
+	if err != ErrFavoriteExists {
+		t.Errorf("FavoritePayment(): must return ErrFavoriteExists, returned: %v", err)
+		return
+	}
+}
+
+func TestService_UpdateFavorite_success(t *testing.T) {
+	s := newTestService()
+	_, _, favorites, err := s.addAccount(defaultTestAccount2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorite, err := s.UpdateFavorite(favorites[0].ID, "car wash", 300_00, "auto")
+	if err != nil {
+		t.Errorf("UpdateFavorite(): error = %v", err)
+		return
+	}
+	if favorite.Name != "car wash" || favorite.Amount != 300_00 {
+		t.Errorf("UpdateFavorite(): favorite didn't change = %v", favorite)
+		return
+	}
+
+	_, err = s.RenameFavorite(favorites[0].ID, favorites[1].Name)
+	if err != ErrFavoriteExists {
+		t.Errorf("RenameFavorite(): must return ErrFavoriteExists, returned: %v", err)
+		return
+	}
+}
+
+func TestService_MoveFavorite_and_Delete(t *testing.T) {
+	s := newTestService()
+	account, _, favorites, err := s.addAccount(defaultTestAccount2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.MoveFavorite(favorites[2].ID, 0)
+	if err != nil {
+		t.Errorf("MoveFavorite(): error = %v", err)
+		return
+	}
+
+	list, err := s.FavoritesByAccount(account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := []string{favorites[2].ID, favorites[0].ID, favorites[1].ID}
+	for i, favorite := range list {
+		if favorite.ID != want[i] || favorite.Position != i {
+			t.Errorf("FavoritesByAccount(): wrong order = %v", list)
+			return
+		}
+	}
+
+	err = s.DeleteFavorite(favorites[0].ID)
+	if err != nil {
+		t.Errorf("DeleteFavorite(): error = %v", err)
+		return
+	}
+
+	list, err = s.FavoritesByAccount(account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(list) != 2 || list[0].ID != favorites[2].ID || list[1].ID != favorites[1].ID || list[1].Position != 1 {
+		t.Errorf("DeleteFavorite(): wrong favorites left = %v", list)
+		return
+	}
+
+	_, err = s.FindFavoriteByID(favorites[0].ID)
+	if err != ErrFavoriteNotFound {
+		t.Errorf("FindFavoriteByID(): must return ErrFavoriteNotFound, returned: %v", err)
+		return
+	}
+}
+
+func TestService_Import_oldFavorites(t *testing.T) {
+	dir := t.TempDir()
+	data := "bf2a8a9d-e9e2-405d-a813-fa06bbfbb2e5;1;Favorite payment_0;100000;auto\n" +
+		"1a54e6f6-e08a-4829-b814-ae5c11a6b7cd;1;my favor payment;100000;auto\n"
+	err := os.WriteFile(filepath.Join(dir, "favorites.dump"), []byte(data), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := newTestService()
+	err = s.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorite, err := s.FindFavoriteByID("1a54e6f6-e08a-4829-b814-ae5c11a6b7cd")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if favorite.Category != "auto" || favorite.Position != 1 {
+		t.Errorf("Import(): wrong favorite = %v", favorite)
+		return
+	}
+}
+
+func TestService_CreateFavorite_and_PayFromFavoriteWith(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	favorite, err := s.CreateFavorite(account.ID, "internet", "internet", 150_00, "contract 1234; flat 5")
+	if err != nil {
+		t.Errorf("CreateFavorite(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayFromFavoriteWith(favorite.ID, 200_00, "за март")
+	if err != nil {
+		t.Errorf("PayFromFavoriteWith(): error = %v", err)
+		return
+	}
+	if payment.Amount != 200_00 || payment.Description != "за март" || payment.FavoriteID != favorite.ID {
+		t.Errorf("PayFromFavoriteWith(): wrong payment = %v", payment)
+		return
+	}
+
+	payment, err = s.PayFromFavorite(favorite.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if payment.Amount != 150_00 || payment.FavoriteID != favorite.ID {
+		t.Errorf("PayFromFavorite(): wrong payment = %v", payment)
+		return
+	}
+
+	if len(s.FavoritePayments(favorite.ID)) != 2 {
+		t.Errorf("FavoritePayments(): wrong payments = %v", s.FavoritePayments(favorite.ID))
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := imported.FindFavoriteByID(favorite.ID)
+	if err != nil || got.Payee != favorite.Payee {
+		t.Errorf("Import(): wrong favorite = %v, error = %v", got, err)
+		return
+	}
+	if len(imported.FavoritePayments(favorite.ID)) != 2 {
+		t.Errorf("Import(): favorite link lost")
+		return
+	}
+}
@@ -0,0 +1,236 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrFeeRuleNotFound = errors.New("fee rule not found")
+var ErrInvalidFeeRule = errors.New("invalid fee rule")
+var ErrRevenueAccountNotSet = errors.New("revenue account is not set")
+
+// FeeRule - правило расчёта комиссии. Пустые Category и Channel и нулевые
+// границы суммы означают "любые". Комиссия равна Flat плюс Percent базисных
+// пунктов от суммы (с округлением до ближайшей минимальной единицы) и
+// ограничивается снизу MinFee и сверху MaxFee, если они заданы.
+type FeeRule struct {
+	ID           string
+	Category     types.PaymentCategory // категория вместе с вложенными
+	Channel      types.PaymentChannel
+	MinAmount    types.Money // минимальная сумма операции
+	MaxAmount    types.Money // максимальная сумма операции
+	Flat         types.Money
+	Percent      int64 // базисные пункты (100 = 1%)
+	MinFee       types.Money
+	MaxFee       types.Money
+	FreePerMonth int // сколько подпадающих под правило операций в месяц счёт совершает бесплатно
+}
+
+// FeeRefundPolicy - возвращается ли комиссия при отклонении платежа.
+type FeeRefundPolicy int
+
+// Предопределённые политики возврата комиссии.
+const (
+	FeeRefundFull FeeRefundPolicy = iota // комиссия возвращается вместе с платежом
+	FeeRefundNone                        // комиссия остаётся у получателя комиссий
+)
+
+// AddFeeRule - добавляет правило комиссии. Для операции применяется первое
+// подходящее правило в порядке добавления.
+func (s *Service) AddFeeRule(rule FeeRule) (*FeeRule, error) {
+	if rule.Flat < 0 || rule.Percent < 0 || rule.MinFee < 0 || rule.MaxFee < 0 ||
+		rule.MinAmount < 0 || rule.MaxAmount < 0 || rule.FreePerMonth < 0 {
+		return nil, ErrInvalidFeeRule
+	}
+	if rule.MaxFee != 0 && rule.MaxFee < rule.MinFee {
+		return nil, ErrInvalidFeeRule
+	}
+	if rule.MaxAmount != 0 && rule.MaxAmount < rule.MinAmount {
+		return nil, ErrInvalidFeeRule
+	}
+
+	rule.ID = uuid.New().String()
+	rule.Category = NormalizeCategory(rule.Category)
+	s.feeRules = append(s.feeRules, &rule)
+	return &rule, nil
+}
+
+// RemoveFeeRule - удаляет правило комиссии.
+func (s *Service) RemoveFeeRule(ruleID string) error {
+	for i, rule := range s.feeRules {
+		if rule.ID == ruleID {
+			s.feeRules = append(s.feeRules[:i], s.feeRules[i+1:]...)
+			return nil
+		}
+	}
+	return ErrFeeRuleNotFound
+}
+
+// FeeRules - возвращает правила комиссий в порядке применения.
+func (s *Service) FeeRules() []FeeRule {
+	rules := []FeeRule{}
+	for _, rule := range s.feeRules {
+		rules = append(rules, *rule)
+	}
+	return rules
+}
+
+// SetRevenueAccount - задаёт счёт, на который зачисляются комиссии.
+func (s *Service) SetRevenueAccount(accountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	err = checkCredit(account)
+	if err != nil {
+		return err
+	}
+
+	s.revenueAccountID = accountID
+	return nil
+}
+
+// SetFeeRefundPolicy - задаёт политику возврата комиссии в Reject.
+func (s *Service) SetFeeRefundPolicy(policy FeeRefundPolicy) {
+	s.feeRefundPolicy = policy
+}
+
+// QuoteFee - рассчитывает комиссию, которую счёт заплатит за операцию сейчас.
+// Для переводов используется канал types.ChannelTransfer и пустая категория.
+func (s *Service) QuoteFee(accountID int64, amount types.Money, category types.PaymentCategory, channel types.PaymentChannel) (types.Money, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return 0, err
+	}
+	return s.calculateFee(accountID, amount, NormalizeCategory(category), channel), nil
+}
+
+// calculateFee рассчитывает комиссию по первому подходящему правилу.
+func (s *Service) calculateFee(accountID int64, amount types.Money, category types.PaymentCategory, channel types.PaymentChannel) types.Money {
+	for _, rule := range s.feeRules {
+		if !s.feeRuleMatches(rule, amount, category, channel) {
+			continue
+		}
+		if rule.FreePerMonth > 0 && s.feeRuleUsage(rule, accountID) < rule.FreePerMonth {
+			return 0
+		}
+		return rule.fee(amount)
+	}
+	return 0
+}
+
+// fee рассчитывает комиссию по правилу для суммы amount.
+func (r *FeeRule) fee(amount types.Money) types.Money {
+	fee := r.Flat + types.Money((int64(amount)*r.Percent+5000)/10000)
+	if fee < r.MinFee {
+		fee = r.MinFee
+	}
+	if r.MaxFee != 0 && fee > r.MaxFee {
+		fee = r.MaxFee
+	}
+	return fee
+}
+
+// feeRuleMatches проверяет, подпадает ли операция под правило.
+func (s *Service) feeRuleMatches(rule *FeeRule, amount types.Money, category types.PaymentCategory, channel types.PaymentChannel) bool {
+	if rule.Category != "" && !s.IsSubcategory(category, rule.Category) {
+		return false
+	}
+	if rule.Channel != "" && rule.Channel != channel {
+		return false
+	}
+	if amount < rule.MinAmount || (rule.MaxAmount != 0 && amount > rule.MaxAmount) {
+		return false
+	}
+	return true
+}
+
+// feeRuleUsage считает операции счёта за текущий месяц, подпадающие под правило:
+// неотклонённые платежи и исходящие переводы.
+func (s *Service) feeRuleUsage(rule *FeeRule, accountID int64) int {
+	from, to := budgetPeriod(types.BudgetPeriodMonthly, s.now())
+	inMonth := func(created int64) bool {
+		t := time.Unix(created, 0)
+		return !t.Before(from) && t.Before(to)
+	}
+
+	count := 0
+	for _, payment := range s.payments {
+		if payment.AccountID != accountID || payment.Status == types.PaymentStatusFail || !inMonth(payment.Created) {
+			continue
+		}
+		if s.feeRuleMatches(rule, payment.Amount, payment.Category, payment.Channel) {
+			count++
+		}
+	}
+	for _, entry := range s.ledger {
+		if entry.AccountID != accountID || entry.Kind != types.LedgerTransferOut || !inMonth(entry.Created) {
+			continue
+		}
+		if s.feeRuleMatches(rule, -entry.Amount, "", types.ChannelTransfer) {
+			count++
+		}
+	}
+	return count
+}
+
+// revenueAccount возвращает счёт, на который зачисляются комиссии.
+func (s *Service) revenueAccount() (*types.Account, error) {
+	if s.revenueAccountID == 0 {
+		return nil, ErrRevenueAccountNotSet
+	}
+	account, err := s.FindAccountByID(s.revenueAccountID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCredit(account)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// refundFee возвращает комиссию отклонённого платежа согласно политике.
+// Комиссия возвращается один раз: повторный вызов ничего не делает.
+func (s *Service) refundFee(payment *types.Payment, account *types.Account) {
+	if payment.Fee == 0 || s.feeRefundPolicy == FeeRefundNone {
+		return
+	}
+
+	var charged *types.LedgerEntry
+	for _, entry := range s.ledger {
+		if entry.Kind != types.LedgerFee || entry.Reference != payment.ID {
+			continue
+		}
+		if entry.Amount < 0 {
+			return
+		}
+		if charged == nil {
+			charged = entry
+		}
+	}
+	if charged == nil {
+		return
+	}
+
+	revenue, err := s.FindAccountByID(charged.AccountID)
+	if err != nil {
+		return
+	}
+	revenue.Balance -= payment.Fee
+	account.Balance += payment.Fee
+	s.addLedgerEntry(revenue.ID, -payment.Fee, types.LedgerFee, payment.ID, s.now())
+}
+
+// chargeTransferFee списывает комиссию за перевод в пользу счёта комиссий.
+func (s *Service) chargeTransferFee(from *types.Account, revenue *types.Account, fee types.Money) {
+	now := s.now()
+	from.Balance -= fee
+	revenue.Balance += fee
+	s.addLedgerEntry(from.ID, -fee, types.LedgerFee, strconv.FormatInt(revenue.ID, 10), now)
+	s.addLedgerEntry(revenue.ID, fee, types.LedgerFee, strconv.FormatInt(from.ID, 10), now)
+}
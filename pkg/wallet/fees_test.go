@@ -0,0 +1,228 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestFeeRule_fee(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   FeeRule
+		amount types.Money
+		want   types.Money
+	}{
+		{name: "flat", rule: FeeRule{Flat: 1_00}, amount: 500_00, want: 1_00},
+		{name: "percent rounded", rule: FeeRule{Percent: 150}, amount: 1_03, want: 2},
+		{name: "flat and percent", rule: FeeRule{Flat: 50, Percent: 100}, amount: 100_00, want: 1_50},
+		{name: "min cap", rule: FeeRule{Percent: 100, MinFee: 5_00}, amount: 100_00, want: 5_00},
+		{name: "max cap", rule: FeeRule{Percent: 100, MaxFee: 5_00}, amount: 1_000_00, want: 5_00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.fee(tt.amount)
+			if got != tt.want {
+				t.Errorf("fee() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_AddFeeRule_invalid(t *testing.T) {
+	s := newTestService()
+
+	_, err := s.AddFeeRule(FeeRule{MinFee: 10, MaxFee: 5})
+	if err != ErrInvalidFeeRule {
+		t.Errorf("AddFeeRule(): must return ErrInvalidFeeRule, returned: %v", err)
+		return
+	}
+	_, err = s.AddFeeRule(FeeRule{Percent: -1})
+	if err != ErrInvalidFeeRule {
+		t.Errorf("AddFeeRule(): must return ErrInvalidFeeRule, returned: %v", err)
+		return
+	}
+}
+
+func TestService_Pay_fee(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.AddFeeRule(FeeRule{Category: "food", Percent: 100})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.AddFeeRule(FeeRule{Channel: types.ChannelWeb, Flat: 2_00, FreePerMonth: 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// комиссия есть, а счёта для неё нет
+	_, err = s.Pay(account.ID, 100_00, "food")
+	if err != ErrRevenueAccountNotSet {
+		t.Errorf("Pay(): must return ErrRevenueAccountNotSet, returned: %v", err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100_00, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	if payment.Fee != 1_00 || account.Balance != 899_00 || revenue.Balance != 1_00 {
+		t.Errorf("Pay(): wrong fee, payment = %v, account = %v, revenue = %v", payment, account, revenue)
+		return
+	}
+
+	// без подходящего правила комиссии нет
+	payment, err = s.Pay(account.ID, 100_00, "auto")
+	if err != nil || payment.Fee != 0 {
+		t.Errorf("Pay(): must be free, payment = %v, error = %v", payment, err)
+		return
+	}
+
+	// первый платёж через WEB в месяце бесплатный
+	for i, want := range []types.Money{0, 2_00} {
+		payment, err = s.PayWithOptions(account.ID, 10_00, "auto", PaymentOptions{Channel: types.ChannelWeb})
+		if err != nil || payment.Fee != want {
+			t.Errorf("PayWithOptions(): payment %v must have fee %v, payment = %v, error = %v", i, want, payment, err)
+			return
+		}
+	}
+
+	// сумма с комиссией больше доступной
+	_, err = s.Pay(account.ID, account.Balance, "food")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+}
+
+func TestService_Reject_feeRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  FeeRefundPolicy
+		balance types.Money
+		revenue types.Money
+	}{
+		{name: "full", policy: FeeRefundFull, balance: 1_000_00, revenue: 0},
+		{name: "none", policy: FeeRefundNone, balance: 999_00, revenue: 1_00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService()
+			account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			revenue, err := s.RegisterAccount("+992000000002")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			err = s.SetRevenueAccount(revenue.ID)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, err = s.AddFeeRule(FeeRule{Flat: 1_00})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.SetFeeRefundPolicy(tt.policy)
+
+			payment, err := s.Pay(account.ID, 100_00, "auto")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			err = s.Reject(payment.ID)
+			if err != nil {
+				t.Errorf("Reject(): error = %v", err)
+				return
+			}
+			if account.Balance != tt.balance || revenue.Balance != tt.revenue {
+				t.Errorf("Reject(): wrong balances, account = %v, revenue = %v", account, revenue)
+				return
+			}
+
+			// комиссия возвращается только один раз
+			s.refundFee(payment, account)
+			if account.Balance != tt.balance || revenue.Balance != tt.revenue {
+				t.Errorf("refundFee(): fee refunded twice, account = %v, revenue = %v", account, revenue)
+				return
+			}
+		})
+	}
+}
+
+func TestService_Transfer_fee(t *testing.T) {
+	s := newTestService()
+	from, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	to, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000003")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.AddFeeRule(FeeRule{Channel: types.ChannelTransfer, Percent: 50, MinAmount: 100_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Transfer(from.ID, to.ID, 50_00)
+	if err != nil || from.Balance != 950_00 {
+		t.Errorf("Transfer(): small transfer must be free, from = %v, error = %v", from, err)
+		return
+	}
+
+	err = s.Transfer(from.ID, to.ID, 200_00)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+	if from.Balance != 749_00 || to.Balance != 250_00 || revenue.Balance != 1_00 {
+		t.Errorf("Transfer(): wrong balances, from = %v, to = %v, revenue = %v", from, to, revenue)
+		return
+	}
+
+	ledger, err := s.AccountLedger(from.ID)
+	if err != nil || len(ledger) != 4 || ledger[3].Kind != types.LedgerFee || ledger[3].Amount != -1_00 {
+		t.Errorf("AccountLedger(): wrong ledger = %v, error = %v", ledger, err)
+		return
+	}
+}
@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+// Размер страницы истории по умолчанию и наибольший допустимый размер.
+const (
+	DefaultHistoryPageSize = 20
+	MaxHistoryPageSize     = 100
+)
+
+// HistoryPage - страница истории платежей счёта, от новых платежей к старым.
+type HistoryPage struct {
+	Payments []types.Payment
+	// Next - курсор страницы с более старыми платежами; пустой, если их нет.
+	Next string
+	// Prev - курсор страницы с более новыми платежами. Для непустой страницы
+	// задан всегда: по нему можно получить платежи, добавленные позже.
+	Prev string
+}
+
+// AccountHistory - возвращает страницу истории платежей счёта. Пустой cursor -
+// первая (самая новая) страница, иначе - HistoryPage.Next или HistoryPage.Prev
+// одной из предыдущих страниц. Курсор указывает на конкретный платёж, поэтому
+// новые платежи не сдвигают страницы и не вызывают повторов или пропусков.
+// Размер страницы limit: 0 - DefaultHistoryPageSize, не больше MaxHistoryPageSize.
+// Если платежей нет, возвращается пустая страница без ошибки.
+func (s *Service) AccountHistory(accountID int64, cursor string, limit int) (HistoryPage, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultHistoryPageSize
+	}
+	if limit > MaxHistoryPageSize {
+		limit = MaxHistoryPageSize
+	}
+
+	backward := false
+	query := NewPaymentQuery(ByAccount(accountID)).Limit(limit)
+	if cursor != "" {
+		var key int64
+		var seq int
+		backward, key, seq, err = decodeHistoryCursor(cursor)
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		query.After(encodeCursor(key, seq))
+	}
+	// назад (к новым платежам) идём по возрастанию и переворачиваем страницу
+	query.OrderBy(SortByCreated, !backward)
+
+	indexes, _, more, err := s.queryPage(query)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+	if backward {
+		for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		}
+	}
+
+	page := HistoryPage{Payments: make([]types.Payment, 0, len(indexes))}
+	for _, index := range indexes {
+		page.Payments = append(page.Payments, *s.payments[index])
+	}
+
+	if len(indexes) == 0 {
+		if backward {
+			page.Prev = cursor
+		}
+		return page, nil
+	}
+
+	newest := indexes[0]
+	oldest := indexes[len(indexes)-1]
+	page.Prev = encodeHistoryCursor(true, s.payments[newest].Created, newest)
+	if backward || more {
+		page.Next = encodeHistoryCursor(false, s.payments[oldest].Created, oldest)
+	}
+	return page, nil
+}
+
+// encodeHistoryCursor кодирует позицию платежа и направление листания.
+func encodeHistoryCursor(backward bool, created int64, seq int) string {
+	direction := "n:"
+	if backward {
+		direction = "p:"
+	}
+	raw := direction + strconv.FormatInt(created, 10) + ":" + strconv.Itoa(seq)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(cursor string) (bool, int64, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) < 2 {
+		return false, 0, 0, ErrInvalidCursor
+	}
+
+	var backward bool
+	switch string(raw[:2]) {
+	case "n:":
+		backward = false
+	case "p:":
+		backward = true
+	default:
+		return false, 0, 0, ErrInvalidCursor
+	}
+
+	created, seq, err := parsePosition(string(raw[2:]))
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return backward, created, seq, nil
+}
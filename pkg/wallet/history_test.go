@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_AccountHistory(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 1; i <= 5; i++ {
+		now = now.Add(time.Minute)
+		_, err = s.Pay(account.ID, types.Money(i), "auto")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	first, err := s.AccountHistory(account.ID, "", 2)
+	if err != nil {
+		t.Errorf("AccountHistory(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(paymentAmounts(first.Payments), []types.Money{5, 4}) || first.Next == "" || first.Prev == "" {
+		t.Errorf("AccountHistory(): wrong first page = %v", first)
+		return
+	}
+
+	// новый платёж не сдвигает следующие страницы
+	now = now.Add(time.Minute)
+	_, err = s.Pay(account.ID, 6, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	second, err := s.AccountHistory(account.ID, first.Next, 2)
+	if err != nil {
+		t.Errorf("AccountHistory(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(paymentAmounts(second.Payments), []types.Money{3, 2}) {
+		t.Errorf("AccountHistory(): wrong second page = %v", paymentAmounts(second.Payments))
+		return
+	}
+	last, err := s.AccountHistory(account.ID, second.Next, 2)
+	if err != nil {
+		t.Errorf("AccountHistory(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(paymentAmounts(last.Payments), []types.Money{1}) || last.Next != "" {
+		t.Errorf("AccountHistory(): wrong last page = %v", last)
+		return
+	}
+
+	back, err := s.AccountHistory(account.ID, last.Prev, 2)
+	if err != nil {
+		t.Errorf("AccountHistory(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(paymentAmounts(back.Payments), []types.Money{3, 2}) || back.Next == "" {
+		t.Errorf("AccountHistory(): wrong previous page = %v", back)
+		return
+	}
+	newer, err := s.AccountHistory(account.ID, first.Prev, 2)
+	if err != nil {
+		t.Errorf("AccountHistory(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(paymentAmounts(newer.Payments), []types.Money{6}) {
+		t.Errorf("AccountHistory(): wrong newer page = %v", paymentAmounts(newer.Payments))
+		return
+	}
+	none, err := s.AccountHistory(account.ID, newer.Prev, 2)
+	if err != nil || len(none.Payments) != 0 || none.Prev != newer.Prev {
+		t.Errorf("AccountHistory(): wrong empty page = %v, error = %v", none, err)
+		return
+	}
+
+	all, err := s.AccountHistory(account.ID, "", MaxHistoryPageSize+1)
+	if err != nil || len(all.Payments) != 6 || all.Next != "" {
+		t.Errorf("AccountHistory(): wrong full page = %v, error = %v", all, err)
+		return
+	}
+
+	_, err = s.AccountHistory(account.ID, "bad", 2)
+	if err != ErrInvalidCursor {
+		t.Errorf("AccountHistory(): must return ErrInvalidCursor, returned: %v", err)
+		return
+	}
+}
+
+func TestService_AccountHistory_empty(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	page, err := s.AccountHistory(account.ID, "", 0)
+	if err != nil || page.Payments == nil || len(page.Payments) != 0 || page.Next != "" || page.Prev != "" {
+		t.Errorf("AccountHistory(): wrong page = %v, error = %v", page, err)
+		return
+	}
+	payments, err := s.ExportAccountHistory(account.ID)
+	if err != nil || payments == nil || len(payments) != 0 {
+		t.Errorf("ExportAccountHistory(): must return empty list, returned %v, error = %v", payments, err)
+		return
+	}
+
+	_, err = s.AccountHistory(account.ID+1, "", 0)
+	if err != ErrAccountNotFound {
+		t.Errorf("AccountHistory(): must return ErrAccountNotFound, returned: %v", err)
+		return
+	}
+}
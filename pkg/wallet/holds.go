@@ -0,0 +1,266 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrHoldNotFound = errors.New("hold not found")
+var ErrHoldNotActive = errors.New("hold is not active")
+var ErrHoldAmountExceeded = errors.New("capture amount exceeds hold amount")
+
+// DefaultHoldTTL - время жизни холда, если оно не задано явно.
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+// PlaceHold - блокирует сумму на счёте без создания платежа. Вместе с суммой
+// блокируется комиссия за её списание по текущим правилам, поэтому списание
+// холда не может не пройти из-за комиссии.
+// Заблокированная сумма уменьшает доступный баланс до списания, отмены или истечения холда.
+func (s *Service) PlaceHold(accountID int64, amount types.Money, category types.PaymentCategory, ttl time.Duration) (*types.Hold, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDebit(account)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err = s.validateCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+
+	fee := s.calculateFee(accountID, amount, category, "")
+	if account.Available() < amount+fee {
+		return nil, ErrNotEnoughBalance
+	}
+
+	err = s.checkLimits(accountID, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+
+	now := s.now()
+	hold := &types.Hold{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Amount:    amount,
+		Fee:       fee,
+		Category:  category,
+		Status:    types.HoldStatusActive,
+		Created:   now.Unix(),
+		Expires:   now.Add(ttl).Unix(),
+	}
+	account.Held += amount + fee
+	s.holds = append(s.holds, hold)
+	return hold, nil
+}
+
+// FindHoldByID - поиск холда по идентификатору.
+func (s *Service) FindHoldByID(holdID string) (*types.Hold, error) {
+	for _, hold := range s.holds {
+		if hold.ID == holdID {
+			return hold, nil
+		}
+	}
+
+	return nil, ErrHoldNotFound
+}
+
+// CaptureHold - списывает всю сумму холда или её часть в платёж,
+// остаток холда освобождается. Если amount равен нулю, списывается вся сумма.
+// Платёж проводится как обычный (PayWithOptions): с комиссией, бюджетами и
+// кешбэком, но комиссия не превышает заблокированной, а лимиты не
+// проверяются повторно. Холд списывается и с замороженного после его
+// установки счёта: деньги уже были зарезервированы. Если платёж не удался,
+// холд остаётся активным.
+func (s *Service) CaptureHold(holdID string, amount types.Money) (*types.Payment, error) {
+	s.ExpireHolds()
+
+	hold, err := s.FindHoldByID(holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hold.Status != types.HoldStatusActive {
+		return nil, ErrHoldNotActive
+	}
+
+	if amount < 0 {
+		return nil, ErrAmountMustBePositive
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return nil, ErrHoldAmountExceeded
+	}
+
+	account, err := s.FindAccountByID(hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// заблокированная сумма переходит в платёж и не должна учитываться
+	// в доступном балансе и лимитах дважды
+	account.Held -= hold.Amount + hold.Fee
+	hold.Status = types.HoldStatusCaptured
+	payment, err := s.pay(hold.AccountID, amount, hold.Category, PaymentOptions{}, hold)
+	if err != nil {
+		account.Held += hold.Amount + hold.Fee
+		hold.Status = types.HoldStatusActive
+		return nil, err
+	}
+
+	hold.PaymentID = payment.ID
+	return payment, nil
+}
+
+// ReleaseHold - отменяет холд и возвращает сумму в доступный баланс.
+func (s *Service) ReleaseHold(holdID string) error {
+	s.ExpireHolds()
+
+	hold, err := s.FindHoldByID(holdID)
+	if err != nil {
+		return err
+	}
+
+	if hold.Status != types.HoldStatusActive {
+		return ErrHoldNotActive
+	}
+
+	return s.closeHold(hold, types.HoldStatusReleased)
+}
+
+// ExpireHolds - освобождает все холды, время жизни которых истекло,
+// и возвращает их количество.
+func (s *Service) ExpireHolds() int {
+	now := s.now().Unix()
+	count := 0
+	for _, hold := range s.holds {
+		if hold.Status != types.HoldStatusActive || hold.Expires > now {
+			continue
+		}
+		err := s.closeHold(hold, types.HoldStatusExpired)
+		if err != nil {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func (s *Service) closeHold(hold *types.Hold, status types.HoldStatus) error {
+	account, err := s.FindAccountByID(hold.AccountID)
+	if err != nil {
+		return err
+	}
+
+	account.Held -= hold.Amount + hold.Fee
+	hold.Status = status
+	return nil
+}
+
+// exportHolds сохраняет холды в dir/holds.dump.
+func (s *Service) exportHolds(dir string) error {
+	if s.holds == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, hold := range s.holds {
+		text := []byte(
+			hold.ID + ";" +
+				strconv.FormatInt(hold.AccountID, 10) + ";" +
+				strconv.FormatInt(int64(hold.Amount), 10) + ";" +
+				escapeField(string(hold.Category)) + ";" +
+				string(hold.Status) + ";" +
+				hold.PaymentID + ";" +
+				strconv.FormatInt(hold.Created, 10) + ";" +
+				strconv.FormatInt(hold.Expires, 10) + ";" +
+				strconv.FormatInt(int64(hold.Fee), 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "holds.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importHolds загружает холды из dir/holds.dump, если файл есть, и
+// пересчитывает заблокированные суммы счетов по активным холдам.
+func (s *Service) importHolds(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "holds.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 8 {
+			continue
+		}
+
+		accountID, _ := strconv.ParseInt(fields[1], 10, 64)
+		amount, _ := strconv.ParseInt(fields[2], 10, 64)
+		created, _ := strconv.ParseInt(fields[6], 10, 64)
+		expires, _ := strconv.ParseInt(fields[7], 10, 64)
+		var fee int64
+		if len(fields) > 8 {
+			fee, _ = strconv.ParseInt(fields[8], 10, 64)
+		}
+
+		hold, err := s.FindHoldByID(fields[0])
+		if err != nil {
+			hold = &types.Hold{ID: fields[0]}
+			s.holds = append(s.holds, hold)
+		}
+		hold.AccountID = accountID
+		hold.Amount = types.Money(amount)
+		hold.Category = types.PaymentCategory(fields[3])
+		hold.Status = types.HoldStatus(fields[4])
+		hold.PaymentID = fields[5]
+		hold.Created = created
+		hold.Expires = expires
+		hold.Fee = types.Money(fee)
+	}
+
+	for _, account := range s.accounts {
+		account.Held = 0
+	}
+	for _, hold := range s.holds {
+		if hold.Status != types.HoldStatusActive {
+			continue
+		}
+		account, err := s.FindAccountByID(hold.AccountID)
+		if err != nil {
+			continue
+		}
+		account.Held += hold.Amount + hold.Fee
+	}
+}
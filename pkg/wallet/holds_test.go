@@ -0,0 +1,237 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_CaptureHold_partial(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.PlaceHold(account.ID, 600_00, "fuel", time.Hour)
+	if err != nil {
+		t.Errorf("PlaceHold(): error = %v", err)
+		return
+	}
+	if account.Balance != 1_000_00 || account.Available() != 400_00 {
+		t.Errorf("PlaceHold(): wrong balances, account = %v", account)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 500_00, "shop")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+
+	payment, err := s.CaptureHold(hold.ID, 450_00)
+	if err != nil {
+		t.Errorf("CaptureHold(): error = %v", err)
+		return
+	}
+	if payment.Amount != 450_00 || payment.Category != "fuel" {
+		t.Errorf("CaptureHold(): wrong payment = %v", payment)
+		return
+	}
+	if account.Balance != 550_00 || account.Available() != 550_00 {
+		t.Errorf("CaptureHold(): wrong balances, account = %v", account)
+		return
+	}
+	if hold.Status != types.HoldStatusCaptured || hold.PaymentID != payment.ID {
+		t.Errorf("CaptureHold(): wrong hold = %v", hold)
+		return
+	}
+
+	_, err = s.CaptureHold(hold.ID, 0)
+	if err != ErrHoldNotActive {
+		t.Errorf("CaptureHold(): must return ErrHoldNotActive, returned: %v", err)
+		return
+	}
+}
+
+func TestService_ReleaseHold(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.PlaceHold(account.ID, 1_000_00, "hotel", 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.ReleaseHold(hold.ID)
+	if err != nil {
+		t.Errorf("ReleaseHold(): error = %v", err)
+		return
+	}
+	if account.Available() != 1_000_00 || hold.Status != types.HoldStatusReleased {
+		t.Errorf("ReleaseHold(): hold not released, account = %v, hold = %v", account, hold)
+		return
+	}
+}
+
+func TestService_ExpireHolds(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.PlaceHold(account.ID, 800_00, "hotel", 2*time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now = now.Add(3 * time.Hour)
+	_, err = s.Pay(account.ID, 900_00, "shop")
+	if err != nil {
+		t.Errorf("Pay(): expired hold must not block balance, error = %v", err)
+		return
+	}
+	if hold.Status != types.HoldStatusExpired {
+		t.Errorf("Pay(): hold must be expired, hold = %v", hold)
+		return
+	}
+
+	_, err = s.CaptureHold(hold.ID, 0)
+	if err != ErrHoldNotActive {
+		t.Errorf("CaptureHold(): must return ErrHoldNotActive, returned: %v", err)
+		return
+	}
+}
+
+func TestService_CaptureHold_notFound(t *testing.T) {
+	s := newTestService()
+
+	_, err := s.CaptureHold("unknown", 0)
+	if err != ErrHoldNotFound {
+		t.Errorf("CaptureHold(): must return ErrHoldNotFound, returned: %v", err)
+		return
+	}
+}
+
+func TestService_CaptureHold_fee(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.AddFeeRule(FeeRule{Flat: 1_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// комиссия блокируется вместе с суммой
+	_, err = s.PlaceHold(account.ID, 1_000_00, "fuel", time.Hour)
+	if err != ErrNotEnoughBalance {
+		t.Errorf("PlaceHold(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+	hold, err := s.PlaceHold(account.ID, 999_00, "fuel", time.Hour)
+	if err != nil {
+		t.Errorf("PlaceHold(): error = %v", err)
+		return
+	}
+	if hold.Fee != 1_00 || account.Available() != 0 {
+		t.Errorf("PlaceHold(): fee must be held, hold = %v, account = %v", hold, account)
+		return
+	}
+
+	// замороженный после установки холда счёт: новые списания запрещены,
+	// а зарезервированная сумма списывается
+	err = s.FreezeAccount(account.ID, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.CaptureHold(hold.ID, 0)
+	if err != nil {
+		t.Errorf("CaptureHold(): error = %v", err)
+		return
+	}
+	if payment.Fee != 1_00 || account.Balance != 0 || account.Held != 0 || revenue.Balance != 1_00 {
+		t.Errorf("CaptureHold(): wrong result, payment = %v, account = %v, revenue = %v", payment, account, revenue)
+		return
+	}
+	err = s.Deposit(account.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.Pay(account.ID, 10_00, "fuel")
+	if err != ErrAccountFrozen {
+		t.Errorf("Pay(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+}
+
+func TestService_Export_holds(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	hold, err := s.PlaceHold(account.ID, 600_00, "fuel", time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := imported.FindHoldByID(hold.ID)
+	if err != nil || *got != *hold {
+		t.Errorf("Import(): wrong hold = %v, want %v, error = %v", got, hold, err)
+		return
+	}
+	importedAccount, err := imported.FindAccountByID(account.ID)
+	if err != nil || importedAccount.Available() != 400_00 {
+		t.Errorf("Import(): reserved funds must stay held, account = %v, error = %v", importedAccount, err)
+		return
+	}
+	_, err = imported.Pay(account.ID, 500_00, "auto")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+}
@@ -0,0 +1,423 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrInstallmentPlanNotFound = errors.New("installment plan not found")
+var ErrInvalidInstallmentPlan = errors.New("invalid installment plan")
+var ErrInvalidInstallmentPolicy = errors.New("invalid installment policy")
+
+// InstallmentPolicy - правила обработки просроченных платежей по рассрочке.
+type InstallmentPolicy struct {
+	GracePeriod time.Duration // сколько после срока платёж можно внести без штрафа
+	LateFee     types.Money   // штраф за платёж, не внесённый в льготный период
+}
+
+// DefaultInstallmentPolicy - политика, используемая, если не задана своя.
+var DefaultInstallmentPolicy = InstallmentPolicy{GracePeriod: 3 * 24 * time.Hour}
+
+// InstallmentRun - результат попытки списать платёж по рассрочке.
+type InstallmentRun struct {
+	PlanID  string
+	Number  int
+	Time    time.Time
+	Payment *types.Payment
+	Err     error
+}
+
+// InstallmentSummary - состояние рассрочки.
+type InstallmentSummary struct {
+	Plan      types.InstallmentPlan
+	Paid      types.Money // внесено по рассрочке без учёта штрафов
+	Remaining types.Money // осталось внести без учёта штрафов
+	LateFees  types.Money // начисленные штрафы
+	Overdue   int         // число просроченных платежей
+	NextDue   time.Time   // срок ближайшего невнесённого платежа, нулевой для погашенной рассрочки
+}
+
+// SetInstallmentPolicy - задаёт политику обработки просрочек по рассрочкам.
+// Штрафы зачисляются на счёт комиссий, поэтому политику со штрафом можно
+// задать только после SetRevenueAccount.
+func (s *Service) SetInstallmentPolicy(policy InstallmentPolicy) error {
+	if policy.LateFee < 0 || policy.GracePeriod < 0 {
+		return ErrInvalidInstallmentPolicy
+	}
+	if policy.LateFee > 0 {
+		_, err := s.revenueAccount()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.installmentPolicy = &policy
+	return nil
+}
+
+// CreateInstallmentPlan - оформляет покупку на amount в рассрочку на count
+// ежемесячных платежей. Первый платёж (вместе с остатком от деления суммы)
+// списывается сразу через PayWithOptions, остальные - каждый месяц в тот же
+// день (см. ProcessInstallments). Оплата счёта на оплату (opts.InvoiceID)
+// в рассрочку не поддерживается.
+func (s *Service) CreateInstallmentPlan(accountID int64, amount types.Money, category types.PaymentCategory, count int, opts PaymentOptions) (*types.InstallmentPlan, error) {
+	if count < 2 || amount < types.Money(count) || opts.InvoiceID != "" {
+		return nil, ErrInvalidInstallmentPlan
+	}
+
+	part := amount / types.Money(count)
+	first := part + amount%types.Money(count)
+
+	payment, err := s.PayWithOptions(accountID, first, category, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	plan := &types.InstallmentPlan{
+		ID:          uuid.New().String(),
+		AccountID:   accountID,
+		Total:       amount,
+		Category:    payment.Category,
+		MerchantID:  opts.MerchantID,
+		Description: opts.Description,
+		Channel:     opts.Channel,
+		Status:      types.InstallmentPlanActive,
+		Created:     now.Unix(),
+	}
+	plan.Installments = append(plan.Installments, types.Installment{
+		Number:    1,
+		Amount:    first,
+		Due:       now.Unix(),
+		Status:    types.InstallmentPaid,
+		PaymentID: payment.ID,
+	})
+	for i := 1; i < count; i++ {
+		plan.Installments = append(plan.Installments, types.Installment{
+			Number: i + 1,
+			Amount: part,
+			Due:    addMonthsClamped(now, i).Unix(),
+			Status: types.InstallmentPending,
+		})
+	}
+
+	s.installmentPlans = append(s.installmentPlans, plan)
+	return plan, nil
+}
+
+// FindInstallmentPlanByID - поиск рассрочки по идентификатору.
+func (s *Service) FindInstallmentPlanByID(planID string) (*types.InstallmentPlan, error) {
+	for _, plan := range s.installmentPlans {
+		if plan.ID == planID {
+			return plan, nil
+		}
+	}
+
+	return nil, ErrInstallmentPlanNotFound
+}
+
+// InstallmentPlans - возвращает все рассрочки счёта.
+func (s *Service) InstallmentPlans(accountID int64) ([]types.InstallmentPlan, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []types.InstallmentPlan{}
+	for _, plan := range s.installmentPlans {
+		if plan.AccountID == accountID {
+			plans = append(plans, copyInstallmentPlan(plan))
+		}
+	}
+	return plans, nil
+}
+
+// InstallmentStatus - возвращает состояние рассрочки.
+func (s *Service) InstallmentStatus(planID string) (InstallmentSummary, error) {
+	plan, err := s.FindInstallmentPlanByID(planID)
+	if err != nil {
+		return InstallmentSummary{}, err
+	}
+
+	summary := InstallmentSummary{Plan: copyInstallmentPlan(plan)}
+	for _, installment := range plan.Installments {
+		summary.LateFees += installment.LateFee
+		if installment.Status == types.InstallmentPaid {
+			summary.Paid += installment.Amount
+			continue
+		}
+
+		summary.Remaining += installment.Amount
+		if installment.Status == types.InstallmentOverdue {
+			summary.Overdue++
+		}
+		if summary.NextDue.IsZero() {
+			summary.NextDue = time.Unix(installment.Due, 0)
+		}
+	}
+	return summary, nil
+}
+
+// ProcessInstallments - списывает платежи по рассрочкам, срок которых
+// наступил, и возвращает результаты попыток. Платежи рассрочки списываются
+// строго по порядку; неудачный платёж становится просроченным и повторяется
+// при следующем вызове. Если просроченный платёж не удалось внести за
+// льготный период, к нему один раз добавляется штраф из политики; платёж,
+// списанный с первой попытки, штрафом не облагается, даже если попытка
+// была позже льготного периода.
+func (s *Service) ProcessInstallments() []InstallmentRun {
+	policy := DefaultInstallmentPolicy
+	if s.installmentPolicy != nil {
+		policy = *s.installmentPolicy
+	}
+
+	now := s.now()
+	runs := []InstallmentRun{}
+	for _, plan := range s.installmentPlans {
+		if plan.Status == types.InstallmentPlanCompleted {
+			continue
+		}
+
+		for i := range plan.Installments {
+			installment := &plan.Installments[i]
+			if installment.Status == types.InstallmentPaid {
+				continue
+			}
+			if installment.Due > now.Unix() {
+				break
+			}
+
+			graceEnd := time.Unix(installment.Due, 0).Add(policy.GracePeriod)
+			if installment.LateFee == 0 && installment.Status == types.InstallmentOverdue && !now.Before(graceEnd) {
+				installment.LateFee = policy.LateFee
+			}
+
+			payment, err := s.payInstallment(plan, installment)
+			runs = append(runs, InstallmentRun{
+				PlanID:  plan.ID,
+				Number:  installment.Number,
+				Time:    now,
+				Payment: payment,
+				Err:     err,
+			})
+			if err != nil {
+				log.Print(err)
+				installment.Status = types.InstallmentOverdue
+				break
+			}
+		}
+		updateInstallmentPlanStatus(plan)
+	}
+	return runs
+}
+
+// payInstallment списывает платёж по рассрочке вместе со штрафом.
+func (s *Service) payInstallment(plan *types.InstallmentPlan, installment *types.Installment) (*types.Payment, error) {
+	account, err := s.FindAccountByID(plan.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revenue *types.Account
+	if installment.LateFee > 0 {
+		revenue, err = s.revenueAccount()
+		if err != nil {
+			return nil, err
+		}
+
+		s.ExpireHolds()
+		fee := s.calculateFee(account.ID, installment.Amount, plan.Category, plan.Channel)
+		if account.Available() < installment.Amount+fee+installment.LateFee {
+			return nil, ErrNotEnoughBalance
+		}
+	}
+
+	payment, err := s.PayWithOptions(account.ID, installment.Amount, plan.Category, PaymentOptions{
+		Description: plan.Description,
+		MerchantID:  plan.MerchantID,
+		Channel:     plan.Channel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if revenue != nil {
+		account.Balance -= installment.LateFee
+		revenue.Balance += installment.LateFee
+		now := s.now()
+		s.addLedgerEntry(account.ID, -installment.LateFee, types.LedgerFee, plan.ID, now)
+		s.addLedgerEntry(revenue.ID, installment.LateFee, types.LedgerFee, plan.ID, now)
+	}
+
+	installment.Status = types.InstallmentPaid
+	installment.PaymentID = payment.ID
+	return payment, nil
+}
+
+// reopenInstallment возвращает в ожидание платёж по рассрочке, оплаченный
+// отклонённым платежом.
+func (s *Service) reopenInstallment(payment *types.Payment) {
+	for _, plan := range s.installmentPlans {
+		for i := range plan.Installments {
+			installment := &plan.Installments[i]
+			if installment.PaymentID != payment.ID {
+				continue
+			}
+
+			installment.PaymentID = ""
+			installment.Status = types.InstallmentPending
+			if installment.Due <= s.now().Unix() {
+				installment.Status = types.InstallmentOverdue
+			}
+			updateInstallmentPlanStatus(plan)
+			return
+		}
+	}
+}
+
+// updateInstallmentPlanStatus пересчитывает статус рассрочки по её платежам.
+func updateInstallmentPlanStatus(plan *types.InstallmentPlan) {
+	plan.Status = types.InstallmentPlanCompleted
+	for _, installment := range plan.Installments {
+		switch installment.Status {
+		case types.InstallmentOverdue:
+			plan.Status = types.InstallmentPlanOverdue
+			return
+		case types.InstallmentPending:
+			plan.Status = types.InstallmentPlanActive
+		}
+	}
+}
+
+// copyInstallmentPlan копирует рассрочку вместе со списком платежей.
+func copyInstallmentPlan(plan *types.InstallmentPlan) types.InstallmentPlan {
+	copied := *plan
+	copied.Installments = append([]types.Installment{}, plan.Installments...)
+	return copied
+}
+
+// exportInstallments сохраняет рассрочки в dir/installment_plans.dump, а их
+// платежи - в dir/installments.dump.
+func (s *Service) exportInstallments(dir string) error {
+	if s.installmentPlans == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	items := make([]byte, 0)
+	for _, plan := range s.installmentPlans {
+		text := []byte(
+			plan.ID + ";" +
+				strconv.FormatInt(plan.AccountID, 10) + ";" +
+				strconv.FormatInt(int64(plan.Total), 10) + ";" +
+				string(plan.Category) + ";" +
+				plan.MerchantID + ";" +
+				escapeField(plan.Description) + ";" +
+				string(plan.Channel) + ";" +
+				string(plan.Status) + ";" +
+				strconv.FormatInt(plan.Created, 10) + "\n")
+
+		data = append(data, text...)
+
+		for _, installment := range plan.Installments {
+			text := []byte(
+				plan.ID + ";" +
+					strconv.Itoa(installment.Number) + ";" +
+					strconv.FormatInt(int64(installment.Amount), 10) + ";" +
+					strconv.FormatInt(installment.Due, 10) + ";" +
+					string(installment.Status) + ";" +
+					installment.PaymentID + ";" +
+					strconv.FormatInt(int64(installment.LateFee), 10) + "\n")
+
+			items = append(items, text...)
+		}
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "installment_plans.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, "installments.dump"), items, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importInstallments загружает рассрочки из dir/installment_plans.dump и их
+// платежи из dir/installments.dump, если файлы есть.
+func (s *Service) importInstallments(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "installment_plans.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	imported := make(map[string]*types.InstallmentPlan)
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 9 {
+			continue
+		}
+
+		accountID, _ := strconv.ParseInt(fields[1], 10, 64)
+		total, _ := strconv.ParseInt(fields[2], 10, 64)
+		created, _ := strconv.ParseInt(fields[8], 10, 64)
+
+		plan, err := s.FindInstallmentPlanByID(fields[0])
+		if err != nil {
+			plan = &types.InstallmentPlan{ID: fields[0]}
+			s.installmentPlans = append(s.installmentPlans, plan)
+		}
+		plan.AccountID = accountID
+		plan.Total = types.Money(total)
+		plan.Category = types.PaymentCategory(fields[3])
+		plan.MerchantID = fields[4]
+		plan.Description = fields[5]
+		plan.Channel = types.PaymentChannel(fields[6])
+		plan.Status = types.InstallmentPlanStatus(fields[7])
+		plan.Created = created
+		plan.Installments = nil
+		imported[plan.ID] = plan
+	}
+
+	file, err = os.ReadFile(filepath.Join(dir, "installments.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 7 {
+			continue
+		}
+
+		plan, ok := imported[fields[0]]
+		if !ok {
+			continue
+		}
+		number, _ := strconv.Atoi(fields[1])
+		amount, _ := strconv.ParseInt(fields[2], 10, 64)
+		due, _ := strconv.ParseInt(fields[3], 10, 64)
+		lateFee, _ := strconv.ParseInt(fields[6], 10, 64)
+		plan.Installments = append(plan.Installments, types.Installment{
+			Number:    number,
+			Amount:    types.Money(amount),
+			Due:       due,
+			Status:    types.InstallmentStatus(fields[4]),
+			PaymentID: fields[5],
+			LateFee:   types.Money(lateFee),
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_CreateInstallmentPlan(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.CreateInstallmentPlan(account.ID, 100_00, "electronics", 1, PaymentOptions{})
+	if err != ErrInvalidInstallmentPlan {
+		t.Errorf("CreateInstallmentPlan(): must return ErrInvalidInstallmentPlan, returned: %v", err)
+		return
+	}
+
+	plan, err := s.CreateInstallmentPlan(account.ID, 1_000_00, "electronics", 3, PaymentOptions{Description: "телефон"})
+	if err != nil {
+		t.Errorf("CreateInstallmentPlan(): error = %v", err)
+		return
+	}
+	if len(plan.Installments) != 3 || plan.Installments[0].Amount != 333_34 || plan.Installments[1].Amount != 333_33 {
+		t.Errorf("CreateInstallmentPlan(): wrong installments = %v", plan.Installments)
+		return
+	}
+	if plan.Installments[0].Status != types.InstallmentPaid || account.Balance != 666_66 {
+		t.Errorf("CreateInstallmentPlan(): first installment must be paid, plan = %v, account = %v", plan, account)
+		return
+	}
+	// 31 января + 1 месяц = 29 февраля
+	if plan.Installments[1].Due != time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("CreateInstallmentPlan(): wrong due = %v", time.Unix(plan.Installments[1].Due, 0).UTC())
+		return
+	}
+
+	now = time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC)
+	runs := s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != nil || runs[0].Number != 2 {
+		t.Errorf("ProcessInstallments(): wrong runs = %v", runs)
+		return
+	}
+
+	now = time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	s.ProcessInstallments()
+	summary, err := s.InstallmentStatus(plan.ID)
+	if err != nil {
+		t.Errorf("InstallmentStatus(): error = %v", err)
+		return
+	}
+	if summary.Plan.Status != types.InstallmentPlanCompleted || summary.Paid != 1_000_00 || summary.Remaining != 0 || !summary.NextDue.IsZero() {
+		t.Errorf("InstallmentStatus(): wrong summary = %v", summary)
+		return
+	}
+	if account.Balance != 0 {
+		t.Errorf("ProcessInstallments(): wrong balance = %v", account.Balance)
+		return
+	}
+}
+
+func TestService_ProcessInstallments_lateFee(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetInstallmentPolicy(InstallmentPolicy{GracePeriod: 48 * time.Hour, LateFee: 5_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	plan, err := s.CreateInstallmentPlan(account.ID, 200_00, "electronics", 2, PaymentOptions{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now = time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	runs := s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != ErrNotEnoughBalance {
+		t.Errorf("ProcessInstallments(): must fail with ErrNotEnoughBalance, runs = %v", runs)
+		return
+	}
+	if plan.Status != types.InstallmentPlanOverdue || plan.Installments[1].LateFee != 0 {
+		t.Errorf("ProcessInstallments(): plan must be overdue without fee, plan = %v", plan)
+		return
+	}
+
+	// внесли деньги после льготного периода - списывается и штраф
+	err = s.Deposit(account.ID, 105_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = now.Add(48 * time.Hour)
+	runs = s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("ProcessInstallments(): wrong runs = %v", runs)
+		return
+	}
+	if account.Balance != 0 || revenue.Balance != 5_00 || plan.Status != types.InstallmentPlanCompleted {
+		t.Errorf("ProcessInstallments(): wrong result, account = %v, revenue = %v, plan = %v", account, revenue, plan)
+		return
+	}
+
+	summary, err := s.InstallmentStatus(plan.ID)
+	if err != nil || summary.LateFees != 5_00 {
+		t.Errorf("InstallmentStatus(): wrong summary = %v, error = %v", summary, err)
+		return
+	}
+
+	// отклонённый платёж снова нужно внести
+	err = s.Reject(plan.Installments[1].PaymentID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if plan.Status != types.InstallmentPlanOverdue {
+		t.Errorf("Reject(): plan must be overdue again, plan = %v", plan)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	plans, err := imported.InstallmentPlans(account.ID)
+	if err != nil || len(plans) != 1 || len(plans[0].Installments) != 2 || plans[0].Installments[1].LateFee != 5_00 {
+		t.Errorf("Import(): plans must be persisted, plans = %v, error = %v", plans, err)
+		return
+	}
+}
+
+func TestService_ProcessInstallments_onTime(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 300_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetInstallmentPolicy(InstallmentPolicy{GracePeriod: 0, LateFee: 50_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	plan, err := s.CreateInstallmentPlan(account.ID, 300_00, "electronics", 3, PaymentOptions{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// в срок
+	now = time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	runs := s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("ProcessInstallments(): wrong runs = %v", runs)
+		return
+	}
+	// первая попытка позже льготного периода (например, планировщик не работал)
+	now = time.Date(2024, time.July, 20, 12, 0, 0, 0, time.UTC)
+	runs = s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("ProcessInstallments(): wrong runs = %v", runs)
+		return
+	}
+
+	if account.Balance != 0 || revenue.Balance != 0 || plan.Status != types.InstallmentPlanCompleted {
+		t.Errorf("ProcessInstallments(): late fee must not be charged, account = %v, revenue = %v, plan = %v", account, revenue, plan)
+		return
+	}
+}
+
+func TestService_SetInstallmentPolicy_noRevenueAccount(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	err := s.SetInstallmentPolicy(InstallmentPolicy{LateFee: 5_00})
+	if err != ErrRevenueAccountNotSet {
+		t.Errorf("SetInstallmentPolicy(): must return ErrRevenueAccountNotSet, returned: %v", err)
+		return
+	}
+	err = s.SetInstallmentPolicy(InstallmentPolicy{GracePeriod: -time.Hour})
+	if err != ErrInvalidInstallmentPolicy {
+		t.Errorf("SetInstallmentPolicy(): must return ErrInvalidInstallmentPolicy, returned: %v", err)
+		return
+	}
+
+	account, err := s.addAccountWithBalance("+992000000001", 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	plan, err := s.CreateInstallmentPlan(account.ID, 200_00, "electronics", 2, PaymentOptions{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now = time.Date(2024, time.June, 10, 12, 0, 0, 0, time.UTC)
+	runs := s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != ErrNotEnoughBalance {
+		t.Errorf("ProcessInstallments(): must fail with ErrNotEnoughBalance, runs = %v", runs)
+		return
+	}
+
+	// просроченный платёж списывается после льготного периода без штрафа
+	err = s.Deposit(account.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = now.Add(30 * 24 * time.Hour)
+	runs = s.ProcessInstallments()
+	if len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("ProcessInstallments(): wrong runs = %v", runs)
+		return
+	}
+	if account.Balance != 0 || plan.Status != types.InstallmentPlanCompleted || plan.Installments[1].LateFee != 0 {
+		t.Errorf("ProcessInstallments(): wrong result, account = %v, plan = %v", account, plan)
+		return
+	}
+}
@@ -0,0 +1,308 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrInvoiceNotFound = errors.New("invoice not found")
+var ErrInvoiceNotPayable = errors.New("invoice is paid or cancelled")
+var ErrInvoiceOverpaid = errors.New("amount exceeds invoice outstanding amount")
+var ErrInvoiceHasPayments = errors.New("invoice has payments")
+var ErrInvalidInvoiceItem = errors.New("invalid invoice item")
+
+// IssueInvoice - выставляет счёт на оплату от имени получателя. Сумма счёта
+// равна сумме позиций, оплатить его нужно до due.
+func (s *Service) IssueInvoice(merchantID string, items []types.InvoiceItem, due time.Time) (*types.Invoice, error) {
+	_, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrInvalidInvoiceItem
+	}
+
+	var amount types.Money
+	for _, item := range items {
+		if strings.TrimSpace(item.Name) == "" || item.Quantity <= 0 || item.Price <= 0 {
+			return nil, ErrInvalidInvoiceItem
+		}
+		amount += item.Price * types.Money(item.Quantity)
+	}
+
+	invoice := &types.Invoice{
+		ID:         uuid.New().String(),
+		MerchantID: merchantID,
+		Amount:     amount,
+		Due:        due.Unix(),
+		Items:      append([]types.InvoiceItem{}, items...),
+		Status:     types.InvoiceStatusOpen,
+		Created:    s.now().Unix(),
+	}
+	s.updateInvoiceStatus(invoice)
+	s.invoices = append(s.invoices, invoice)
+	return invoice, nil
+}
+
+// FindInvoiceByID - поиск счёта на оплату по идентификатору.
+func (s *Service) FindInvoiceByID(invoiceID string) (*types.Invoice, error) {
+	invoice := s.invoiceByID(invoiceID)
+	if invoice == nil {
+		return nil, ErrInvoiceNotFound
+	}
+
+	s.updateInvoiceStatus(invoice)
+	return invoice, nil
+}
+
+// PayInvoice - оплачивает счёт целиком (amount равен нулю) или частично.
+// Платёж зачисляется получателю, выставившему счёт, и ссылается на счёт.
+func (s *Service) PayInvoice(accountID int64, invoiceID string, amount types.Money) (*types.Payment, error) {
+	invoice, err := s.payableInvoice(invoiceID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount == 0 {
+		amount = invoice.Amount - invoice.Paid
+	}
+	return s.PayWithOptions(accountID, amount, "", PaymentOptions{InvoiceID: invoiceID})
+}
+
+// CancelInvoice - отменяет счёт на оплату, по которому ещё нет оплат.
+func (s *Service) CancelInvoice(invoiceID string) error {
+	invoice, err := s.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case invoice.Status == types.InvoiceStatusCancelled:
+		return nil
+	case invoice.Paid != 0:
+		return ErrInvoiceHasPayments
+	}
+
+	invoice.Status = types.InvoiceStatusCancelled
+	return nil
+}
+
+// MarkOverdueInvoices - помечает просроченными неоплаченные счета, срок
+// которых прошёл, и возвращает количество помеченных.
+func (s *Service) MarkOverdueInvoices() int {
+	count := 0
+	for _, invoice := range s.invoices {
+		if invoice.Status == types.InvoiceStatusOverdue {
+			continue
+		}
+		s.updateInvoiceStatus(invoice)
+		if invoice.Status == types.InvoiceStatusOverdue {
+			count++
+		}
+	}
+	return count
+}
+
+// MerchantInvoices - возвращает счета, выставленные получателем.
+func (s *Service) MerchantInvoices(merchantID string) ([]types.Invoice, error) {
+	_, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.MarkOverdueInvoices()
+	invoices := []types.Invoice{}
+	for _, invoice := range s.invoices {
+		if invoice.MerchantID == merchantID {
+			invoices = append(invoices, *invoice)
+		}
+	}
+	return invoices, nil
+}
+
+// InvoicePayments - возвращает все платежи по счёту, включая отклонённые.
+func (s *Service) InvoicePayments(invoiceID string) ([]types.Payment, error) {
+	_, err := s.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []types.Payment{}
+	for _, payment := range s.payments {
+		if payment.InvoiceID == invoiceID {
+			payments = append(payments, *payment)
+		}
+	}
+	return payments, nil
+}
+
+// payableInvoice возвращает счёт, если на него можно заплатить amount.
+func (s *Service) payableInvoice(invoiceID string, amount types.Money) (*types.Invoice, error) {
+	invoice, err := s.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch invoice.Status {
+	case types.InvoiceStatusPaid, types.InvoiceStatusCancelled:
+		return nil, ErrInvoiceNotPayable
+	}
+	if amount > invoice.Amount-invoice.Paid {
+		return nil, ErrInvoiceOverpaid
+	}
+	return invoice, nil
+}
+
+// addInvoicePayment учитывает платёж (или его отмену при отрицательном amount) в счёте.
+func (s *Service) addInvoicePayment(invoiceID string, amount types.Money) {
+	invoice, err := s.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return
+	}
+
+	invoice.Paid += amount
+	if invoice.Paid < 0 {
+		invoice.Paid = 0
+	}
+	s.updateInvoiceStatus(invoice)
+}
+
+// updateInvoiceStatus пересчитывает статус счёта по оплатам и сроку.
+func (s *Service) updateInvoiceStatus(invoice *types.Invoice) {
+	switch {
+	case invoice.Status == types.InvoiceStatusCancelled:
+	case invoice.Paid >= invoice.Amount:
+		invoice.Status = types.InvoiceStatusPaid
+	case invoice.Due <= s.now().Unix():
+		invoice.Status = types.InvoiceStatusOverdue
+	case invoice.Paid > 0:
+		invoice.Status = types.InvoiceStatusPartiallyPaid
+	default:
+		invoice.Status = types.InvoiceStatusOpen
+	}
+}
+
+// exportInvoices сохраняет счета на оплату в dir/invoices.dump, а их
+// позиции - в dir/invoice_items.dump.
+func (s *Service) exportInvoices(dir string) error {
+	if s.invoices == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	items := make([]byte, 0)
+	for _, invoice := range s.invoices {
+		text := []byte(
+			invoice.ID + ";" +
+				invoice.MerchantID + ";" +
+				strconv.FormatInt(int64(invoice.Amount), 10) + ";" +
+				strconv.FormatInt(int64(invoice.Paid), 10) + ";" +
+				strconv.FormatInt(invoice.Due, 10) + ";" +
+				string(invoice.Status) + ";" +
+				strconv.FormatInt(invoice.Created, 10) + "\n")
+
+		data = append(data, text...)
+
+		for _, item := range invoice.Items {
+			text := []byte(
+				invoice.ID + ";" +
+					escapeField(item.Name) + ";" +
+					strconv.FormatInt(item.Quantity, 10) + ";" +
+					strconv.FormatInt(int64(item.Price), 10) + "\n")
+
+			items = append(items, text...)
+		}
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "invoices.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, "invoice_items.dump"), items, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importInvoices загружает счета на оплату из dir/invoices.dump и их позиции
+// из dir/invoice_items.dump, если файлы есть.
+func (s *Service) importInvoices(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "invoices.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	imported := make(map[string]*types.Invoice)
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 7 {
+			continue
+		}
+
+		amount, _ := strconv.ParseInt(fields[2], 10, 64)
+		paid, _ := strconv.ParseInt(fields[3], 10, 64)
+		due, _ := strconv.ParseInt(fields[4], 10, 64)
+		created, _ := strconv.ParseInt(fields[6], 10, 64)
+
+		invoice := s.invoiceByID(fields[0])
+		if invoice == nil {
+			invoice = &types.Invoice{ID: fields[0]}
+			s.invoices = append(s.invoices, invoice)
+		}
+		invoice.MerchantID = fields[1]
+		invoice.Amount = types.Money(amount)
+		invoice.Paid = types.Money(paid)
+		invoice.Due = due
+		invoice.Status = types.InvoiceStatus(fields[5])
+		invoice.Created = created
+		invoice.Items = nil
+		imported[invoice.ID] = invoice
+	}
+
+	file, err = os.ReadFile(filepath.Join(dir, "invoice_items.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		invoice, ok := imported[fields[0]]
+		if !ok {
+			continue
+		}
+		quantity, _ := strconv.ParseInt(fields[2], 10, 64)
+		price, _ := strconv.ParseInt(fields[3], 10, 64)
+		invoice.Items = append(invoice.Items, types.InvoiceItem{
+			Name:     fields[1],
+			Quantity: quantity,
+			Price:    types.Money(price),
+		})
+	}
+}
+
+// invoiceByID ищет счёт на оплату без пересчёта статуса.
+func (s *Service) invoiceByID(invoiceID string) *types.Invoice {
+	for _, invoice := range s.invoices {
+		if invoice.ID == invoiceID {
+			return invoice
+		}
+	}
+	return nil
+}
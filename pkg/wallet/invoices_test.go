@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var testInvoiceItems = []types.InvoiceItem{
+	{Name: "Плов", Quantity: 2, Price: 45_00},
+	{Name: "Чай; зелёный", Quantity: 1, Price: 10_00},
+}
+
+func TestService_PayInvoice(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	settlement, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Чайхана", "restaurant", settlement.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.IssueInvoice(merchant.ID, []types.InvoiceItem{{Name: "", Quantity: 1, Price: 1}}, now)
+	if err != ErrInvalidInvoiceItem {
+		t.Errorf("IssueInvoice(): must return ErrInvalidInvoiceItem, returned: %v", err)
+		return
+	}
+
+	invoice, err := s.IssueInvoice(merchant.ID, testInvoiceItems, now.Add(72*time.Hour))
+	if err != nil {
+		t.Errorf("IssueInvoice(): error = %v", err)
+		return
+	}
+	if invoice.Amount != 100_00 || invoice.Status != types.InvoiceStatusOpen {
+		t.Errorf("IssueInvoice(): wrong invoice = %v", invoice)
+		return
+	}
+
+	payment, err := s.PayInvoice(account.ID, invoice.ID, 40_00)
+	if err != nil {
+		t.Errorf("PayInvoice(): error = %v", err)
+		return
+	}
+	if payment.InvoiceID != invoice.ID || payment.MerchantID != merchant.ID || payment.Category != "restaurant" {
+		t.Errorf("PayInvoice(): wrong payment = %v", payment)
+		return
+	}
+	if invoice.Paid != 40_00 || invoice.Status != types.InvoiceStatusPartiallyPaid || settlement.Balance != 40_00 {
+		t.Errorf("PayInvoice(): wrong invoice = %v, settlement = %v", invoice, settlement)
+		return
+	}
+
+	_, err = s.PayInvoice(account.ID, invoice.ID, 60_01)
+	if err != ErrInvoiceOverpaid {
+		t.Errorf("PayInvoice(): must return ErrInvoiceOverpaid, returned: %v", err)
+		return
+	}
+	err = s.CancelInvoice(invoice.ID)
+	if err != ErrInvoiceHasPayments {
+		t.Errorf("CancelInvoice(): must return ErrInvoiceHasPayments, returned: %v", err)
+		return
+	}
+
+	// остаток
+	_, err = s.PayInvoice(account.ID, invoice.ID, 0)
+	if err != nil {
+		t.Errorf("PayInvoice(): error = %v", err)
+		return
+	}
+	if invoice.Paid != 100_00 || invoice.Status != types.InvoiceStatusPaid || account.Balance != 900_00 {
+		t.Errorf("PayInvoice(): wrong invoice = %v, account = %v", invoice, account)
+		return
+	}
+	_, err = s.PayInvoice(account.ID, invoice.ID, 1)
+	if err != ErrInvoiceNotPayable {
+		t.Errorf("PayInvoice(): must return ErrInvoiceNotPayable, returned: %v", err)
+		return
+	}
+	_, err = s.PayInvoice(account.ID, invoice.ID, 0)
+	if err != ErrInvoiceNotPayable {
+		t.Errorf("PayInvoice(): must return ErrInvoiceNotPayable, returned: %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if invoice.Paid != 60_00 || invoice.Status != types.InvoiceStatusPartiallyPaid {
+		t.Errorf("Reject(): invoice must be reopened, invoice = %v", invoice)
+		return
+	}
+
+	payments, err := s.InvoicePayments(invoice.ID)
+	if err != nil || len(payments) != 2 {
+		t.Errorf("InvoicePayments(): wrong payments = %v, error = %v", payments, err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindInvoiceByID(invoice.ID)
+	if err != nil || got.Paid != 60_00 || len(got.Items) != 2 || got.Items[1] != testInvoiceItems[1] {
+		t.Errorf("Import(): invoice must be persisted, invoice = %v, error = %v", got, err)
+		return
+	}
+	gotPayment, err := imported.FindPaymentById(payment.ID)
+	if err != nil || gotPayment.InvoiceID != invoice.ID {
+		t.Errorf("Import(): payment invoice must be persisted, payment = %v, error = %v", gotPayment, err)
+		return
+	}
+}
+
+func TestService_MarkOverdueInvoices(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Чайхана", "restaurant", account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	overdue, err := s.IssueInvoice(merchant.ID, testInvoiceItems, now.Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cancelled, err := s.IssueInvoice(merchant.ID, testInvoiceItems, now.Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.CancelInvoice(cancelled.ID)
+	if err != nil {
+		t.Errorf("CancelInvoice(): error = %v", err)
+		return
+	}
+	_, err = s.PayInvoice(account.ID, cancelled.ID, 0)
+	if err != ErrInvoiceNotPayable {
+		t.Errorf("PayInvoice(): must return ErrInvoiceNotPayable, returned: %v", err)
+		return
+	}
+
+	now = now.Add(time.Hour)
+	count := s.MarkOverdueInvoices()
+	if count != 1 || overdue.Status != types.InvoiceStatusOverdue || cancelled.Status != types.InvoiceStatusCancelled {
+		t.Errorf("MarkOverdueInvoices(): wrong result, count = %v, overdue = %v, cancelled = %v", count, overdue, cancelled)
+		return
+	}
+
+	// просроченный счёт всё ещё можно оплатить
+	_, err = s.PayInvoice(account.ID, overdue.ID, 0)
+	if err != nil || overdue.Status != types.InvoiceStatusPaid {
+		t.Errorf("PayInvoice(): overdue invoice must be paid, invoice = %v, error = %v", overdue, err)
+		return
+	}
+}
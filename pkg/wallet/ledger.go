@@ -0,0 +1,105 @@
+package wallet
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+// AccountLedger - возвращает движения денег по счёту (зачисления, проценты,
+// переводы) в порядке их проведения. Платежи хранятся отдельно.
+func (s *Service) AccountLedger(accountID int64) ([]types.LedgerEntry, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []types.LedgerEntry{}
+	for _, entry := range s.ledger {
+		if entry.AccountID == accountID {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// addLedgerEntry записывает движение денег по счёту на момент at.
+func (s *Service) addLedgerEntry(accountID int64, amount types.Money, kind types.LedgerKind, reference string, at time.Time) *types.LedgerEntry {
+	entry := &types.LedgerEntry{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Amount:    amount,
+		Kind:      kind,
+		Reference: reference,
+		Created:   at.Unix(),
+	}
+	s.ledger = append(s.ledger, entry)
+	return entry
+}
+
+// exportLedger сохраняет движения по счетам в dir/ledger.dump.
+func (s *Service) exportLedger(dir string) error {
+	if s.ledger == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, entry := range s.ledger {
+		text := []byte(
+			entry.ID + ";" +
+				strconv.FormatInt(entry.AccountID, 10) + ";" +
+				strconv.FormatInt(int64(entry.Amount), 10) + ";" +
+				string(entry.Kind) + ";" +
+				escapeField(entry.Reference) + ";" +
+				strconv.FormatInt(entry.Created, 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "ledger.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importLedger загружает движения по счетам из dir/ledger.dump, если файл есть.
+func (s *Service) importLedger(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "ledger.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	known := make(map[string]bool)
+	for _, entry := range s.ledger {
+		known[entry.ID] = true
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 6 || known[fields[0]] {
+			continue
+		}
+
+		accountID, _ := strconv.ParseInt(fields[1], 10, 64)
+		amount, _ := strconv.ParseInt(fields[2], 10, 64)
+		created, _ := strconv.ParseInt(fields[5], 10, 64)
+		s.ledger = append(s.ledger, &types.LedgerEntry{
+			ID:        fields[0],
+			AccountID: accountID,
+			Amount:    types.Money(amount),
+			Kind:      types.LedgerKind(fields[3]),
+			Reference: fields[4],
+			Created:   created,
+		})
+		known[fields[0]] = true
+	}
+}
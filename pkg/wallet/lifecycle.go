@@ -0,0 +1,174 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrAccountFrozen = errors.New("account is frozen")
+var ErrAccountClosed = errors.New("account is closed")
+var ErrAccountHasBalance = errors.New("account has non-zero balance")
+var ErrSameAccount = errors.New("source and destination accounts are the same")
+
+// FreezeAccount - замораживает аккаунт: списания запрещаются, а если
+// blockCredits равен true, запрещаются и зачисления.
+func (s *Service) FreezeAccount(accountID int64, blockCredits bool) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	account.Status = types.AccountStatusFrozen
+	if blockCredits {
+		account.Status = types.AccountStatusBlocked
+	}
+	return nil
+}
+
+// UnfreezeAccount - снимает заморозку с аккаунта.
+func (s *Service) UnfreezeAccount(accountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	account.Status = types.AccountStatusActive
+	return nil
+}
+
+// CloseAccount - закрывает аккаунт. Если на счёте остались деньги, они
+// переводятся на payoutAccountID; без него закрыть можно только пустой счёт.
+// Счёт с долгом или активными холдами закрыть нельзя.
+func (s *Service) CloseAccount(accountID int64, payoutAccountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	s.ExpireHolds()
+	if account.Balance < 0 || account.Held != 0 {
+		return ErrAccountHasBalance
+	}
+
+	if account.Balance > 0 {
+		if payoutAccountID == 0 {
+			return ErrAccountHasBalance
+		}
+		if payoutAccountID == accountID {
+			return ErrSameAccount
+		}
+
+		payout, err := s.FindAccountByID(payoutAccountID)
+		if err != nil {
+			return err
+		}
+		err = checkCredit(payout)
+		if err != nil {
+			return err
+		}
+
+		s.moveMoney(account, payout, account.Balance)
+	}
+
+	account.Status = types.AccountStatusClosed
+	return nil
+}
+
+// Transfer - переводит деньги между аккаунтами. Комиссия за перевод (канал
+// types.ChannelTransfer) списывается с отправителя сверх суммы перевода;
+// переводы между счетами одного клиента бесплатны.
+func (s *Service) Transfer(fromAccountID int64, toAccountID int64, amount types.Money) error {
+	if amount <= 0 {
+		return ErrAmountMustBePositive
+	}
+	if fromAccountID == toAccountID {
+		return ErrSameAccount
+	}
+
+	from, err := s.FindAccountByID(fromAccountID)
+	if err != nil {
+		return err
+	}
+	to, err := s.FindAccountByID(toAccountID)
+	if err != nil {
+		return err
+	}
+
+	err = checkDebit(from)
+	if err != nil {
+		return err
+	}
+	err = checkCredit(to)
+	if err != nil {
+		return err
+	}
+
+	s.ExpireHolds()
+	var fee types.Money
+	if from.CustomerID == 0 || from.CustomerID != to.CustomerID {
+		fee = s.calculateFee(fromAccountID, amount, "", types.ChannelTransfer)
+	}
+	if from.Available() < amount+fee {
+		return ErrNotEnoughBalance
+	}
+
+	var revenue *types.Account
+	if fee > 0 {
+		revenue, err = s.revenueAccount()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.moveMoney(from, to, amount)
+	if revenue != nil {
+		s.chargeTransferFee(from, revenue, fee)
+	}
+	return nil
+}
+
+// moveMoney переводит деньги между счетами и записывает движения по обоим счетам.
+func (s *Service) moveMoney(from *types.Account, to *types.Account, amount types.Money) {
+	now := s.now()
+	from.Balance -= amount
+	to.Balance += amount
+	s.addLedgerEntry(from.ID, -amount, types.LedgerTransferOut, strconv.FormatInt(to.ID, 10), now)
+	s.addLedgerEntry(to.ID, amount, types.LedgerTransferIn, strconv.FormatInt(from.ID, 10), now)
+}
+
+// checkDebit проверяет, что с аккаунта можно списывать деньги.
+// Пустой статус (аккаунты из старых дампов) считается активным.
+func checkDebit(account *types.Account) error {
+	switch account.Status {
+	case types.AccountStatusClosed:
+		return ErrAccountClosed
+	case types.AccountStatusFrozen, types.AccountStatusBlocked:
+		return ErrAccountFrozen
+	}
+	return nil
+}
+
+// checkCredit проверяет, что на аккаунт можно зачислять деньги.
+func checkCredit(account *types.Account) error {
+	switch account.Status {
+	case types.AccountStatusClosed:
+		return ErrAccountClosed
+	case types.AccountStatusBlocked:
+		return ErrAccountFrozen
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_FreezeAccount(t *testing.T) {
+	s := newTestService()
+	account, payments, favorites, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.FreezeAccount(account.ID, false)
+	if err != nil {
+		t.Errorf("FreezeAccount(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != ErrAccountFrozen {
+		t.Errorf("Pay(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+	_, err = s.Repeat(payments[0].ID)
+	if err != ErrAccountFrozen {
+		t.Errorf("Repeat(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+	_, err = s.PayFromFavorite(favorites[0].ID)
+	if err != ErrAccountFrozen {
+		t.Errorf("PayFromFavorite(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+
+	// зачисления разрешены
+	err = s.Deposit(account.ID, 100)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	err = s.FreezeAccount(account.ID, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Deposit(account.ID, 100)
+	if err != ErrAccountFrozen {
+		t.Errorf("Deposit(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+
+	err = s.UnfreezeAccount(account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
+
+func TestService_Transfer(t *testing.T) {
+	s := newTestService()
+	from, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	to, err := s.addAccountWithBalance("+992000000002", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Transfer(from.ID, to.ID, 400_00)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+	if from.Balance != 600_00 || to.Balance != 400_01 {
+		t.Errorf("Transfer(): wrong balances, from = %v, to = %v", from, to)
+		return
+	}
+
+	err = s.Transfer(from.ID, to.ID, 600_01)
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Transfer(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+
+	err = s.FreezeAccount(to.ID, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Transfer(from.ID, to.ID, 100)
+	if err != ErrAccountFrozen {
+		t.Errorf("Transfer(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+}
+
+func TestService_CloseAccount(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payout, err := s.addAccountWithBalance("+992000000002", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.CloseAccount(account.ID, 0)
+	if err != ErrAccountHasBalance {
+		t.Errorf("CloseAccount(): must return ErrAccountHasBalance, returned: %v", err)
+		return
+	}
+
+	err = s.CloseAccount(account.ID, payout.ID)
+	if err != nil {
+		t.Errorf("CloseAccount(): error = %v", err)
+		return
+	}
+	if account.Balance != 0 || payout.Balance != 1_000_01 || account.Status != types.AccountStatusClosed {
+		t.Errorf("CloseAccount(): wrong result, account = %v, payout = %v", account, payout)
+		return
+	}
+
+	err = s.Deposit(account.ID, 100)
+	if err != ErrAccountClosed {
+		t.Errorf("Deposit(): must return ErrAccountClosed, returned: %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindAccountByID(account.ID)
+	if err != nil || got.Status != types.AccountStatusClosed {
+		t.Errorf("Import(): status must be persisted, account = %v, error = %v", got, err)
+		return
+	}
+}
@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitKind - вид превышенного лимита.
+type LimitKind string
+
+// Виды лимитов.
+const (
+	LimitPerTransaction LimitKind = "PER_TRANSACTION"
+	LimitDaily          LimitKind = "DAILY"
+	LimitMonthly        LimitKind = "MONTHLY"
+)
+
+// Limits - лимиты расходов. Нулевое значение означает отсутствие лимита.
+type Limits struct {
+	PerTransaction types.Money
+	Daily          types.Money
+	Monthly        types.Money
+}
+
+// LimitExceededError - ошибка превышения лимита, содержит остаток доступной суммы.
+type LimitExceededError struct {
+	AccountID int64
+	Category  types.PaymentCategory // пустая категория - лимит на весь аккаунт
+	Kind      LimitKind
+	Limit     types.Money
+	Remaining types.Money
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Category == "" {
+		return fmt.Sprintf("%s limit %d exceeded for account %d, remaining %d",
+			e.Kind, e.Limit, e.AccountID, e.Remaining)
+	}
+	return fmt.Sprintf("%s limit %d exceeded for account %d in category %s, remaining %d",
+		e.Kind, e.Limit, e.AccountID, e.Category, e.Remaining)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrLimitExceeded).
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+type limitKey struct {
+	accountID int64
+	category  types.PaymentCategory
+}
+
+// SetLimits - устанавливает лимиты аккаунта. Если категория пустая, лимиты
+// действуют на все платежи аккаунта, иначе - только на платежи этой категории.
+func (s *Service) SetLimits(accountID int64, category types.PaymentCategory, limits Limits) error {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if limits.PerTransaction < 0 || limits.Daily < 0 || limits.Monthly < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	if s.limits == nil {
+		s.limits = make(map[limitKey]Limits)
+	}
+	s.limits[limitKey{accountID, category}] = limits
+	return nil
+}
+
+// RemoveLimits - снимает лимиты аккаунта для категории.
+func (s *Service) RemoveLimits(accountID int64, category types.PaymentCategory) {
+	delete(s.limits, limitKey{accountID, category})
+}
+
+// FindLimits - возвращает лимиты аккаунта для категории.
+func (s *Service) FindLimits(accountID int64, category types.PaymentCategory) (Limits, bool) {
+	limits, ok := s.limits[limitKey{accountID, category}]
+	return limits, ok
+}
+
+// checkLimits проверяет, что платёж не превышает лимиты аккаунта:
+// сначала общие, затем по категории.
+func (s *Service) checkLimits(accountID int64, amount types.Money, category types.PaymentCategory) error {
+	if len(s.limits) == 0 {
+		return nil
+	}
+
+	keys := []limitKey{{accountID, ""}}
+	if category != "" {
+		keys = append(keys, limitKey{accountID, category})
+	}
+
+	now := s.now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	for _, key := range keys {
+		limits, ok := s.limits[key]
+		if !ok {
+			continue
+		}
+
+		if limits.PerTransaction > 0 && amount > limits.PerTransaction {
+			return &LimitExceededError{
+				AccountID: accountID,
+				Category:  key.category,
+				Kind:      LimitPerTransaction,
+				Limit:     limits.PerTransaction,
+				Remaining: limits.PerTransaction,
+			}
+		}
+
+		if limits.Daily > 0 {
+			spent := s.spentSince(key, dayStart)
+			if spent+amount > limits.Daily {
+				return &LimitExceededError{
+					AccountID: accountID,
+					Category:  key.category,
+					Kind:      LimitDaily,
+					Limit:     limits.Daily,
+					Remaining: remaining(limits.Daily, spent),
+				}
+			}
+		}
+
+		if limits.Monthly > 0 {
+			spent := s.spentSince(key, monthStart)
+			if spent+amount > limits.Monthly {
+				return &LimitExceededError{
+					AccountID: accountID,
+					Category:  key.category,
+					Kind:      LimitMonthly,
+					Limit:     limits.Monthly,
+					Remaining: remaining(limits.Monthly, spent),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// spentSince считает сумму неотклонённых платежей и активных холдов аккаунта
+// (и категории) начиная с from.
+func (s *Service) spentSince(key limitKey, from time.Time) types.Money {
+	spent := types.Money(0)
+	for _, payment := range s.payments {
+		if payment.AccountID != key.accountID || payment.Status == types.PaymentStatusFail {
+			continue
+		}
+		if key.category != "" && payment.Category != key.category {
+			continue
+		}
+		if payment.Created < from.Unix() {
+			continue
+		}
+		spent += payment.Amount
+	}
+
+	// активные холды уже зарезервировали часть лимита
+	for _, hold := range s.holds {
+		if hold.AccountID != key.accountID || hold.Status != types.HoldStatusActive {
+			continue
+		}
+		if key.category != "" && hold.Category != key.category {
+			continue
+		}
+		if hold.Created < from.Unix() {
+			continue
+		}
+		spent += hold.Amount
+	}
+	return spent
+}
+
+func remaining(limit types.Money, spent types.Money) types.Money {
+	if spent >= limit {
+		return 0
+	}
+	return limit - spent
+}
@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_SetLimits_perTransaction(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetLimits(account.ID, "", Limits{PerTransaction: 1_000_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 1_000_01, "auto")
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("Pay(): must return LimitExceededError, returned: %v", err)
+		return
+	}
+	if limitErr.Kind != LimitPerTransaction {
+		t.Errorf("Pay(): wrong limit kind = %v", limitErr.Kind)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 1_000_00, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
+
+func TestService_SetLimits_dailyAndMonthly(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.March, 31, 10, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetLimits(account.ID, "restaurant", Limits{Daily: 500_00, Monthly: 800_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 400_00, "restaurant")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	// другая категория лимитом не ограничена
+	_, err = s.Pay(account.ID, 2_000_00, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 200_00, "restaurant")
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitDaily {
+		t.Errorf("Pay(): must return daily LimitExceededError, returned: %v", err)
+		return
+	}
+	if limitErr.Remaining != 100_00 {
+		t.Errorf("Pay(): wrong remaining = %v", limitErr.Remaining)
+		return
+	}
+
+	// на следующий день (уже апрель) дневной и месячный лимиты начинаются заново
+	now = now.Add(24 * time.Hour)
+	_, err = s.Pay(account.ID, 500_00, "restaurant")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 300_00, "restaurant")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must return ErrLimitExceeded, returned: %v", err)
+		return
+	}
+}
+
+func TestService_SetLimits_holds(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetLimits(account.ID, "", Limits{Daily: 500_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.PlaceHold(account.ID, 300_00, "fuel", time.Hour)
+	if err != nil {
+		t.Errorf("PlaceHold(): error = %v", err)
+		return
+	}
+	_, err = s.PlaceHold(account.ID, 300_00, "fuel", time.Hour)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("PlaceHold(): must return ErrLimitExceeded, returned: %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 300_00, "auto")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must return ErrLimitExceeded, returned: %v", err)
+		return
+	}
+
+	// списание холда не учитывается в лимите дважды
+	_, err = s.CaptureHold(hold.ID, 0)
+	if err != nil {
+		t.Errorf("CaptureHold(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 200_00, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
+
+func TestService_SetLimits_rejectedNotCounted(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetLimits(account.ID, "", Limits{Daily: 1_000_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 1_000_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Repeat(payment.ID)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Repeat(): must return ErrLimitExceeded, returned: %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Repeat(payment.ID)
+	if err != nil {
+		t.Errorf("Repeat(): error = %v", err)
+		return
+	}
+}
+
+func TestService_SetLimits_notFound(t *testing.T) {
+	s := newTestService()
+
+	err := s.SetLimits(1, "", Limits{Daily: types.Money(100)})
+	if err != ErrAccountNotFound {
+		t.Errorf("SetLimits(): must return ErrAccountNotFound, returned: %v", err)
+		return
+	}
+}
@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrMerchantNotFound = errors.New("merchant not found")
+
+// RegisterMerchant - регистрирует получателя платежей. Платежи в его пользу
+// зачисляются на счёт accountID.
+func (s *Service) RegisterMerchant(name string, category types.PaymentCategory, accountID int64) (*types.Merchant, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant := &types.Merchant{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Category:  category,
+		AccountID: accountID,
+	}
+	s.merchants = append(s.merchants, merchant)
+	return merchant, nil
+}
+
+// FindMerchantByID - поиск получателя по идентификатору.
+func (s *Service) FindMerchantByID(merchantID string) (*types.Merchant, error) {
+	for _, merchant := range s.merchants {
+		if merchant.ID == merchantID {
+			return merchant, nil
+		}
+	}
+
+	return nil, ErrMerchantNotFound
+}
+
+// PayMerchant - совершает платёж в пользу получателя в его категории.
+func (s *Service) PayMerchant(accountID int64, merchantID string, amount types.Money) (*types.Payment, error) {
+	return s.PayWithOptions(accountID, amount, "", PaymentOptions{MerchantID: merchantID})
+}
+
+// MerchantPayments - возвращает все платежи в пользу получателя.
+func (s *Service) MerchantPayments(merchantID string) ([]types.Payment, error) {
+	_, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FilterPaymentsByFn(FilterMerchant(merchantID), 1)
+}
+
+// ExportMerchantPayments - сохраняет платежи получателя в файлы, как HistoryToFiles.
+func (s *Service) ExportMerchantPayments(merchantID string, dir string, records int) error {
+	payments, err := s.MerchantPayments(merchantID)
+	if err != nil {
+		return err
+	}
+
+	return s.HistoryToFiles(payments, dir, records)
+}
+
+// FilterMerchant - возвращает фильтр платежей по получателю для FilterPaymentsByFn.
+func FilterMerchant(merchantID string) func(payment types.Payment) bool {
+	return func(payment types.Payment) bool {
+		return payment.MerchantID == merchantID
+	}
+}
+
+// exportMerchants сохраняет получателей в dir/merchants.dump.
+func (s *Service) exportMerchants(dir string) error {
+	if s.merchants == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, merchant := range s.merchants {
+		text := []byte(
+			merchant.ID + ";" +
+				escapeField(merchant.Name) + ";" +
+				string(merchant.Category) + ";" +
+				strconv.FormatInt(merchant.AccountID, 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "merchants.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importMerchants загружает получателей из dir/merchants.dump, если файл есть.
+func (s *Service) importMerchants(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "merchants.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		accountID, _ := strconv.ParseInt(fields[3], 10, 64)
+
+		merchant, err := s.FindMerchantByID(fields[0])
+		if err != nil {
+			merchant = &types.Merchant{ID: fields[0]}
+			s.merchants = append(s.merchants, merchant)
+		}
+		merchant.Name = fields[1]
+		merchant.Category = types.PaymentCategory(fields[2])
+		merchant.AccountID = accountID
+	}
+}
@@ -0,0 +1,277 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_PayMerchant_success(t *testing.T) {
+	s := newTestService()
+	customer, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	settlement, err := s.addAccountWithBalance("+992000000002", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	merchant, err := s.RegisterMerchant("Apteka #1", "medicine", settlement.ID)
+	if err != nil {
+		t.Errorf("RegisterMerchant(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayMerchant(customer.ID, merchant.ID, 300_00)
+	if err != nil {
+		t.Errorf("PayMerchant(): error = %v", err)
+		return
+	}
+	if payment.MerchantID != merchant.ID || payment.Category != "medicine" {
+		t.Errorf("PayMerchant(): wrong payment = %v", payment)
+		return
+	}
+	if customer.Balance != 700_00 || settlement.Balance != 300_01 {
+		t.Errorf("PayMerchant(): wrong balances, customer = %v, settlement = %v", customer, settlement)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if customer.Balance != 1_000_00 || settlement.Balance != 1 {
+		t.Errorf("Reject(): wrong balances, customer = %v, settlement = %v", customer, settlement)
+		return
+	}
+}
+
+func TestService_Reject_twice(t *testing.T) {
+	s := newTestService()
+	customer, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	settlement, err := s.addAccountWithBalance("+992000000002", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Apteka #1", "medicine", settlement.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.PayMerchant(customer.ID, merchant.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	err = s.Reject(payment.ID)
+	if err != ErrPaymentRejected {
+		t.Errorf("Reject(): must return ErrPaymentRejected, returned: %v", err)
+		return
+	}
+	if customer.Balance != 1_000_00 || settlement.Balance != 1 {
+		t.Errorf("Reject(): wrong balances, customer = %v, settlement = %v", customer, settlement)
+		return
+	}
+}
+
+func TestService_Reject_accountStatus(t *testing.T) {
+	s := newTestService()
+	customer, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	settlement, err := s.addAccountWithBalance("+992000000002", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := s.addAccountWithBalance("+992000000003", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Apteka #1", "medicine", settlement.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.PayMerchant(customer.ID, merchant.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// заблокированный плательщик не может получить деньги обратно
+	err = s.FreezeAccount(customer.ID, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(payment.ID)
+	if err != ErrAccountFrozen {
+		t.Errorf("Reject(): must return ErrAccountFrozen, returned: %v", err)
+		return
+	}
+	err = s.UnfreezeAccount(customer.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// со счёта получателя нельзя списать больше, чем на нём есть
+	err = s.Transfer(settlement.ID, other.ID, 50_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(payment.ID)
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Reject(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+
+	// закрытый счёт получателя
+	err = s.Transfer(other.ID, settlement.ID, 50_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.CloseAccount(settlement.ID, other.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(payment.ID)
+	if err != ErrAccountClosed {
+		t.Errorf("Reject(): must return ErrAccountClosed, returned: %v", err)
+		return
+	}
+	if customer.Balance != 900_00 || settlement.Balance != 0 || payment.Status == types.PaymentStatusFail {
+		t.Errorf("Reject(): nothing must change, customer = %v, settlement = %v, payment = %v", customer, settlement, payment)
+		return
+	}
+}
+
+func TestService_MerchantPayments(t *testing.T) {
+	s := newTestService()
+	customer, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	settlement, err := s.addAccountWithBalance("+992000000002", 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Tcell", "phone", settlement.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.PayMerchant(customer.ID, merchant.ID, 10_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.Pay(customer.ID, 20_00, "phone")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayMerchant(customer.ID, merchant.ID, 30_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payments, err := s.MerchantPayments(merchant.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(payments) != 2 {
+		t.Errorf("MerchantPayments(): wrong payments = %v", payments)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.ExportMerchantPayments(merchant.ID, dir, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "payments.dump"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if strings.Count(string(data), "\n") != 2 {
+		t.Errorf("ExportMerchantPayments(): wrong dump = %v", string(data))
+		return
+	}
+
+	_, err = s.MerchantPayments("unknown")
+	if err != ErrMerchantNotFound {
+		t.Errorf("MerchantPayments(): must return ErrMerchantNotFound, returned: %v", err)
+		return
+	}
+}
+
+func TestService_Export_merchants(t *testing.T) {
+	s := newTestService()
+	customer, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Кафе; Чайхана", "restaurant", customer.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := imported.FindMerchantByID(merchant.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if *got != *merchant {
+		t.Errorf("Import(): wrong merchant = %v, want %v", got, merchant)
+		return
+	}
+}
@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrInvalidPaymentTag = errors.New("payment tag is empty")
+var ErrInvalidMetadataKey = errors.New("payment metadata key is empty")
+
+// PaymentDetails - сведения, которые можно добавить к уже совершённому платежу.
+type PaymentDetails struct {
+	Description string            // заменяет примечание, если не пустое
+	Tags        []string          // добавляются к меткам платежа
+	ExternalRef string            // заменяет внешний номер, если не пустой
+	Metadata    map[string]string // задаёт значения ключей; пустое значение удаляет ключ
+}
+
+// AnnotatePayment - дополняет платёж примечанием, метками, внешним номером и
+// данными "ключ - значение", например, чтобы связать его с заказом.
+func (s *Service) AnnotatePayment(paymentID string, details PaymentDetails) error {
+	payment, err := s.FindPaymentById(paymentID)
+	if err != nil {
+		return err
+	}
+
+	tags, err := normalizeTags(append(append([]string{}, payment.Tags...), details.Tags...))
+	if err != nil {
+		return err
+	}
+	for key := range details.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return ErrInvalidMetadataKey
+		}
+	}
+
+	if details.Description != "" {
+		payment.Description = details.Description
+	}
+	if details.ExternalRef != "" {
+		payment.ExternalRef = strings.TrimSpace(details.ExternalRef)
+	}
+	payment.Tags = tags
+	for key, value := range details.Metadata {
+		key = strings.TrimSpace(key)
+		if value == "" {
+			delete(payment.Metadata, key)
+			continue
+		}
+		if payment.Metadata == nil {
+			payment.Metadata = make(map[string]string)
+		}
+		payment.Metadata[key] = value
+	}
+	if len(payment.Metadata) == 0 {
+		payment.Metadata = nil
+	}
+	return nil
+}
+
+// FilterTag - возвращает фильтр платежей с меткой tag (без учёта регистра)
+// для FilterPaymentsByFn.
+func FilterTag(tag string) func(payment types.Payment) bool {
+	tag = strings.TrimSpace(tag)
+	return func(payment types.Payment) bool {
+		for _, paymentTag := range payment.Tags {
+			if strings.EqualFold(paymentTag, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterExternalRef - возвращает фильтр платежей по внешнему номеру для FilterPaymentsByFn.
+func FilterExternalRef(ref string) func(payment types.Payment) bool {
+	ref = strings.TrimSpace(ref)
+	return func(payment types.Payment) bool {
+		return payment.ExternalRef == ref
+	}
+}
+
+// FilterMetadata - возвращает фильтр платежей, у которых ключ key равен value,
+// для FilterPaymentsByFn. Пустой value подходит к любому значению ключа.
+func FilterMetadata(key string, value string) func(payment types.Payment) bool {
+	return func(payment types.Payment) bool {
+		got, ok := payment.Metadata[key]
+		return ok && (value == "" || got == value)
+	}
+}
+
+// FilterDescription - возвращает фильтр платежей, в примечании которых есть
+// text (без учёта регистра), для FilterPaymentsByFn.
+func FilterDescription(text string) func(payment types.Payment) bool {
+	text = strings.ToLower(text)
+	return func(payment types.Payment) bool {
+		return strings.Contains(strings.ToLower(payment.Description), text)
+	}
+}
+
+// normalizeTags обрезает пробелы и убирает повторы меток без учёта регистра.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, ErrInvalidPaymentTag
+		}
+		if seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// copyMetadata копирует данные платежа, проверяя ключи.
+func copyMetadata(metadata map[string]string) (map[string]string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, ErrInvalidMetadataKey
+		}
+		copied[key] = value
+	}
+	return copied, nil
+}
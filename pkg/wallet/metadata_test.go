@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var testPaymentOptions = PaymentOptions{
+	Description: "заказ; доставка\nдо двери",
+	Tags:        []string{"office", " Office ", "a,b=c|d;e"},
+	ExternalRef: "ORD-1;2",
+	Metadata:    map[string]string{"order": "42", "note=x": "a,b\\c;d|e"},
+}
+
+func TestService_PayWithOptions_metadata(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.PayWithOptions(account.ID, 10_00, "auto", PaymentOptions{Tags: []string{" "}})
+	if err != ErrInvalidPaymentTag {
+		t.Errorf("PayWithOptions(): must return ErrInvalidPaymentTag, returned: %v", err)
+		return
+	}
+	_, err = s.PayWithOptions(account.ID, 10_00, "auto", PaymentOptions{Metadata: map[string]string{"": "x"}})
+	if err != ErrInvalidMetadataKey {
+		t.Errorf("PayWithOptions(): must return ErrInvalidMetadataKey, returned: %v", err)
+		return
+	}
+
+	payment, err := s.PayWithOptions(account.ID, 10_00, "auto", testPaymentOptions)
+	if err != nil {
+		t.Errorf("PayWithOptions(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(payment.Tags, []string{"office", "a,b=c|d;e"}) || payment.ExternalRef != "ORD-1;2" || len(payment.Metadata) != 2 {
+		t.Errorf("PayWithOptions(): wrong payment = %v", payment)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindPaymentById(payment.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got.Description != payment.Description || !reflect.DeepEqual(got.Tags, payment.Tags) ||
+		got.ExternalRef != payment.ExternalRef || !reflect.DeepEqual(got.Metadata, payment.Metadata) {
+		t.Errorf("Import(): metadata must be persisted, got = %v, want = %v", got, payment)
+		return
+	}
+
+	historyDir := filepath.Join(dir, "history")
+	err = s.HistoryToFiles([]types.Payment{*payment}, historyDir, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	file, err := os.ReadFile(filepath.Join(historyDir, "payments.dump"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fields := splitFields(strings.TrimSuffix(string(file), "\n"))
+	if len(fields) != 9 || fields[5] != payment.Description || !reflect.DeepEqual(splitList(fields[6]), payment.Tags) ||
+		fields[7] != payment.ExternalRef || !reflect.DeepEqual(splitMap(fields[8]), payment.Metadata) {
+		t.Errorf("HistoryToFiles(): wrong line = %q", file)
+		return
+	}
+}
+
+func TestService_AnnotatePayment(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.PayWithOptions(account.ID, 10_00, "auto", PaymentOptions{
+		Tags:     []string{"office"},
+		Metadata: map[string]string{"order": "42", "stale": "1"},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := s.Pay(account.ID, 20_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.AnnotatePayment(payment.ID, PaymentDetails{
+		Description: "Обед с клиентом",
+		Tags:        []string{"OFFICE", "lunch"},
+		ExternalRef: "ORD-42",
+		Metadata:    map[string]string{"stale": "", "ticket": "SUP-7"},
+	})
+	if err != nil {
+		t.Errorf("AnnotatePayment(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(payment.Tags, []string{"office", "lunch"}) ||
+		!reflect.DeepEqual(payment.Metadata, map[string]string{"order": "42", "ticket": "SUP-7"}) {
+		t.Errorf("AnnotatePayment(): wrong payment = %v", payment)
+		return
+	}
+
+	filters := []struct {
+		name   string
+		filter func(payment types.Payment) bool
+	}{
+		{name: "tag", filter: FilterTag("Lunch")},
+		{name: "external ref", filter: FilterExternalRef("ORD-42")},
+		{name: "metadata", filter: FilterMetadata("ticket", "SUP-7")},
+		{name: "metadata key", filter: FilterMetadata("order", "")},
+		{name: "description", filter: FilterDescription("клиент")},
+	}
+	for _, tt := range filters {
+		found, err := s.FilterPaymentsByFn(tt.filter, 2)
+		if err != nil || len(found) != 1 || found[0].ID != payment.ID {
+			t.Errorf("FilterPaymentsByFn(): %v filter must find one payment, found = %v, error = %v", tt.name, found, err)
+			return
+		}
+	}
+
+	err = s.AnnotatePayment(other.ID, PaymentDetails{Metadata: map[string]string{" ": "x"}})
+	if err != ErrInvalidMetadataKey {
+		t.Errorf("AnnotatePayment(): must return ErrInvalidMetadataKey, returned: %v", err)
+		return
+	}
+}
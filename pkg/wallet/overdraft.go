@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrInvalidCreditLimit = errors.New("invalid credit limit")
+var ErrInvalidOverdraftPolicy = errors.New("invalid overdraft policy")
+
+// OverdraftPolicy - плата за пользование овердрафтом. Нулевая политика
+// (по умолчанию) означает бесплатный овердрафт.
+type OverdraftPolicy struct {
+	DailyFee     types.Money // фиксированная плата за каждый день с отрицательным балансом
+	InterestRate int64       // годовая ставка на сумму долга в базисных пунктах (100 = 1%)
+}
+
+// SetOverdraftPolicy - задаёт плату за овердрафт для всех счетов. Плата
+// зачисляется на счёт комиссий, поэтому платную политику можно задать только
+// после SetRevenueAccount.
+func (s *Service) SetOverdraftPolicy(policy OverdraftPolicy) error {
+	if policy.DailyFee < 0 || policy.InterestRate < 0 {
+		return ErrInvalidOverdraftPolicy
+	}
+	if policy != (OverdraftPolicy{}) {
+		_, err := s.revenueAccount()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.overdraftPolicy = policy
+	return nil
+}
+
+// SetOverdraft - одобряет счёту овердрафт: баланс может уйти в минус до limit.
+// Нулевой limit отключает овердрафт. Если долг уже больше нового лимита,
+// списания будут запрещены, пока долг не погасят.
+func (s *Service) SetOverdraft(accountID int64, limit types.Money) error {
+	if limit < 0 {
+		return ErrInvalidCreditLimit
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	account.CreditLimit = limit
+	if account.ChargedAt == 0 {
+		account.ChargedAt = startOfDay(s.now()).Unix()
+	}
+	return nil
+}
+
+// ChargeOverdraft - списывает плату за овердрафт за каждый полностью прошедший
+// день, в конце которого баланс счёта был отрицательным, и возвращает списания.
+//
+// За день списывается DailyFee плюс проценты debt * rate / 10000 / N, где N -
+// 365 или 366 дней в году; проценты округляются вверх до минимальной единицы.
+// Плата списывается даже сверх лимита овердрафта и даже с замороженного счёта
+// и зачисляется на счёт комиссий. Если счёт комиссий недоступен (например,
+// закрыт), плата не списывается, а дни остаются неоплаченными до следующего
+// вызова.
+//
+// Как и AccrueInterest, функция использует текущий баланс счёта, поэтому её
+// нужно вызывать ежедневно.
+func (s *Service) ChargeOverdraft() []types.LedgerEntry {
+	today := startOfDay(s.now())
+
+	var revenue *types.Account
+	if s.overdraftPolicy != (OverdraftPolicy{}) {
+		account, err := s.revenueAccount()
+		if err != nil {
+			log.Print(err)
+			return []types.LedgerEntry{}
+		}
+		revenue = account
+	}
+
+	charged := []types.LedgerEntry{}
+	for _, account := range s.accounts {
+		if account.ChargedAt == 0 && account.Balance >= 0 {
+			continue
+		}
+		if revenue != nil && account.ID == revenue.ID {
+			continue
+		}
+		charged = append(charged, s.chargeAccount(account, revenue, today)...)
+	}
+	return charged
+}
+
+// chargeAccount списывает плату за овердрафт по счёту за дни до today в
+// пользу revenue (nil, если политика бесплатная).
+func (s *Service) chargeAccount(account *types.Account, revenue *types.Account, today time.Time) []types.LedgerEntry {
+	charged := []types.LedgerEntry{}
+	if account.ChargedAt == 0 {
+		account.ChargedAt = today.Unix()
+		return charged
+	}
+
+	day := time.Unix(account.ChargedAt, 0).In(today.Location())
+	for day.Before(today) {
+		next := day.AddDate(0, 0, 1)
+		fee := s.overdraftFee(account.Balance, day)
+		if fee > 0 && revenue != nil {
+			account.Balance -= fee
+			revenue.Balance += fee
+			entry := s.addLedgerEntry(account.ID, -fee, types.LedgerOverdraft, strconv.FormatInt(revenue.ID, 10), next)
+			s.addLedgerEntry(revenue.ID, fee, types.LedgerOverdraft, strconv.FormatInt(account.ID, 10), next)
+			charged = append(charged, *entry)
+		}
+		day = next
+	}
+
+	account.ChargedAt = today.Unix()
+	return charged
+}
+
+// overdraftFee возвращает плату за день day при балансе balance.
+func (s *Service) overdraftFee(balance types.Money, day time.Time) types.Money {
+	if balance >= 0 {
+		return 0
+	}
+
+	interest := dailyInterest(-balance, s.overdraftPolicy.InterestRate, day)
+	fee := s.overdraftPolicy.DailyFee + types.Money(interest/accruedScale)
+	if interest%accruedScale != 0 {
+		fee++
+	}
+	return fee
+}
@@ -0,0 +1,179 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_SetOverdraft(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetOverdraft(account.ID, -1)
+	if err != ErrInvalidCreditLimit {
+		t.Errorf("SetOverdraft(): must return ErrInvalidCreditLimit, returned: %v", err)
+		return
+	}
+	err = s.SetOverdraft(account.ID, 500_00)
+	if err != nil {
+		t.Errorf("SetOverdraft(): error = %v", err)
+		return
+	}
+	if account.Available() != 600_00 || account.AvailableCredit() != 500_00 {
+		t.Errorf("SetOverdraft(): wrong available = %v, credit = %v", account.Available(), account.AvailableCredit())
+		return
+	}
+
+	_, err = s.Pay(account.ID, 300_00, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	if account.Balance != -200_00 || account.AvailableCredit() != 300_00 {
+		t.Errorf("Pay(): wrong account = %v, credit = %v", account, account.AvailableCredit())
+		return
+	}
+
+	_, err = s.Pay(account.ID, 300_01, "auto")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+
+	err = s.CloseAccount(account.ID, 0)
+	if err != ErrAccountHasBalance {
+		t.Errorf("CloseAccount(): must return ErrAccountHasBalance, returned: %v", err)
+		return
+	}
+
+	// лимит ниже текущего долга запрещает новые списания
+	err = s.SetOverdraft(account.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if account.AvailableCredit() != 0 {
+		t.Errorf("AvailableCredit(): must be 0, returned: %v", account.AvailableCredit())
+		return
+	}
+	_, err = s.Pay(account.ID, 1, "auto")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned: %v", err)
+		return
+	}
+}
+
+func TestService_ChargeOverdraft(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2023, time.March, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	err := s.SetOverdraftPolicy(OverdraftPolicy{DailyFee: 1_00})
+	if err != ErrRevenueAccountNotSet {
+		t.Errorf("SetOverdraftPolicy(): must return ErrRevenueAccountNotSet, returned: %v", err)
+		return
+	}
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetOverdraftPolicy(OverdraftPolicy{DailyFee: 1_00, InterestRate: 3650})
+	if err != nil {
+		t.Errorf("SetOverdraftPolicy(): error = %v", err)
+		return
+	}
+	err = s.SetOverdraft(account.ID, 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now = time.Date(2023, time.March, 2, 9, 0, 0, 0, time.UTC)
+	charged := s.ChargeOverdraft()
+	if len(charged) != 0 {
+		t.Errorf("ChargeOverdraft(): positive balance must not be charged, charged = %v", charged)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 1_000_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 2 дня: 1.00 + 0.1% от долга в день (3650 bps / 365), проценты округляются вверх
+	now = time.Date(2023, time.March, 4, 9, 0, 0, 0, time.UTC)
+	charged = s.ChargeOverdraft()
+	if len(charged) != 2 || charged[0].Amount != -2_00 || charged[1].Amount != -2_01 || charged[1].Kind != types.LedgerOverdraft {
+		t.Errorf("ChargeOverdraft(): wrong charged = %v", charged)
+		return
+	}
+	if account.Balance != -1_004_01 || revenue.Balance != 4_01 {
+		t.Errorf("ChargeOverdraft(): wrong balances, account = %v, revenue = %v", account, revenue)
+		return
+	}
+	ledger, err := s.AccountLedger(revenue.ID)
+	if err != nil || len(ledger) != 2 || ledger[1].Amount != 2_01 || ledger[1].Kind != types.LedgerOverdraft {
+		t.Errorf("AccountLedger(): wrong revenue ledger = %v, error = %v", ledger, err)
+		return
+	}
+
+	// счёт комиссий заблокирован - плата не списывается, дни остаются неоплаченными
+	err = s.FreezeAccount(revenue.ID, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = time.Date(2023, time.March, 5, 9, 0, 0, 0, time.UTC)
+	charged = s.ChargeOverdraft()
+	if len(charged) != 0 || account.Balance != -1_004_01 {
+		t.Errorf("ChargeOverdraft(): must not charge without revenue account, charged = %v", charged)
+		return
+	}
+	err = s.UnfreezeAccount(revenue.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	charged = s.ChargeOverdraft()
+	if len(charged) != 1 || revenue.Balance != 6_02 {
+		t.Errorf("ChargeOverdraft(): wrong charged = %v, revenue = %v", charged, revenue)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindAccountByID(account.ID)
+	if err != nil || got.CreditLimit != 1_000_00 || got.ChargedAt != account.ChargedAt {
+		t.Errorf("Import(): overdraft must be persisted, account = %v, error = %v", got, err)
+		return
+	}
+}
@@ -0,0 +1,309 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone")
+
+// InvalidPhoneError - ошибка валидации номера телефона с указанием причины.
+type InvalidPhoneError struct {
+	Phone  types.Phone
+	Reason string
+}
+
+func (e *InvalidPhoneError) Error() string {
+	return fmt.Sprintf("invalid phone %q: %s", string(e.Phone), e.Reason)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrInvalidPhone).
+func (e *InvalidPhoneError) Unwrap() error {
+	return ErrInvalidPhone
+}
+
+// phoneRule - правила номеров страны: код страны, длина национального номера
+// и префикс внутренних номеров, заменяемый на код страны. Если national равен
+// true, номер из digits цифр без кода и префикса считается номером этой страны.
+type phoneRule struct {
+	country  string
+	code     string
+	digits   int
+	trunk    string
+	national bool
+}
+
+// Коды с общим началом должны идти от длинного к короткому.
+var phoneRules = []phoneRule{
+	{country: "TJ", code: "992", digits: 9, national: true},
+	{country: "UZ", code: "998", digits: 9},
+	{country: "RU", code: "7", digits: 10, trunk: "8"},
+}
+
+// NormalizePhone - приводит номер к формату E.164 ("+992000000001"):
+// убирает пробелы, скобки и дефисы, заменяет "00" и внутренний префикс на код
+// страны, дополняет кодом 992 национальные номера Таджикистана из 9 цифр и
+// проверяет длину номера для Таджикистана, России и Узбекистана.
+func NormalizePhone(phone types.Phone) (types.Phone, error) {
+	number := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "", "\t", "").Replace(string(phone))
+	if number == "" {
+		return "", &InvalidPhoneError{Phone: phone, Reason: "empty number"}
+	}
+
+	plus := strings.HasPrefix(number, "+")
+	number = strings.TrimPrefix(number, "+")
+	if !plus && strings.HasPrefix(number, "00") {
+		number = number[2:]
+		plus = true
+	}
+
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", &InvalidPhoneError{Phone: phone, Reason: "unexpected characters"}
+		}
+	}
+
+	if !plus {
+		for _, rule := range phoneRules {
+			if rule.trunk != "" && len(number) == len(rule.trunk)+rule.digits && strings.HasPrefix(number, rule.trunk) {
+				number = rule.code + number[len(rule.trunk):]
+				break
+			}
+			if rule.national && len(number) == rule.digits {
+				number = rule.code + number
+				break
+			}
+		}
+	}
+
+	for _, rule := range phoneRules {
+		if !strings.HasPrefix(number, rule.code) {
+			continue
+		}
+		if len(number) != len(rule.code)+rule.digits {
+			return "", &InvalidPhoneError{
+				Phone:  phone,
+				Reason: fmt.Sprintf("%s numbers must have %d digits after +%s", rule.country, rule.digits, rule.code),
+			}
+		}
+		return types.Phone("+" + number), nil
+	}
+
+	// остальные страны проверяем только по общим правилам E.164
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", &InvalidPhoneError{Phone: phone, Reason: "not an E.164 number"}
+	}
+	return types.Phone("+" + number), nil
+}
+
+// samePhone сравнивает номера с учётом нормализации. Номера, которые не
+// удаётся нормализовать (например, из старых дампов), сравниваются как есть.
+func samePhone(a types.Phone, b types.Phone) bool {
+	return normalizeOrRaw(a) == normalizeOrRaw(b)
+}
+
+func normalizeOrRaw(phone types.Phone) types.Phone {
+	normalized, err := NormalizePhone(phone)
+	if err != nil {
+		return phone
+	}
+	return normalized
+}
+
+// accountByPhone ищет аккаунт по номеру телефона с учётом нормализации.
+// Если у клиента несколько счетов, возвращается основной.
+func (s *Service) accountByPhone(phone types.Phone) (*types.Account, error) {
+	for _, account := range s.accounts {
+		if !samePhone(account.Phone, phone) {
+			continue
+		}
+
+		customer, err := s.FindCustomerByID(account.CustomerID)
+		if err == nil && customer.DefaultAccountID != account.ID {
+			defaultAccount, err := s.FindAccountByID(customer.DefaultAccountID)
+			if err == nil {
+				return defaultAccount, nil
+			}
+		}
+		return account, nil
+	}
+	return nil, ErrAccountNotFound
+}
+
+// PhoneDuplicate - группа аккаунтов, номера которых совпадают после нормализации.
+type PhoneDuplicate struct {
+	Phone      types.Phone
+	AccountIDs []int64
+}
+
+// PhoneReport - отчёт о проблемных номерах в существующих данных.
+type PhoneReport struct {
+	Duplicates []PhoneDuplicate
+	Invalid    []int64 // аккаунты с номерами, не прошедшими валидацию
+}
+
+// CheckPhones - ищет аккаунты с одинаковыми после нормализации номерами
+// и аккаунты с некорректными номерами.
+func (s *Service) CheckPhones() PhoneReport {
+	report := PhoneReport{}
+	groups := make(map[types.Phone][]int64)
+	order := []types.Phone{}
+
+	for _, account := range s.accounts {
+		// дополнительные счета клиента делят номер с основным
+		customer, err := s.FindCustomerByID(account.CustomerID)
+		if err == nil && customer.DefaultAccountID != account.ID {
+			continue
+		}
+
+		phone, err := NormalizePhone(account.Phone)
+		if err != nil {
+			report.Invalid = append(report.Invalid, account.ID)
+			continue
+		}
+		if _, ok := groups[phone]; !ok {
+			order = append(order, phone)
+		}
+		groups[phone] = append(groups[phone], account.ID)
+	}
+
+	for _, phone := range order {
+		if len(groups[phone]) > 1 {
+			report.Duplicates = append(report.Duplicates, PhoneDuplicate{Phone: phone, AccountIDs: groups[phone]})
+		}
+	}
+	return report
+}
+
+// FindAccountByPhone - поиск аккаунта по номеру телефона в любом формате.
+func (s *Service) FindAccountByPhone(phone types.Phone) (*types.Account, error) {
+	return s.accountByPhone(phone)
+}
+
+// ChangePhone - меняет номер телефона аккаунта и всех счетов его клиента.
+// Новый номер не должен принадлежать другому клиенту; прежний номер
+// сохраняется в истории.
+func (s *Service) ChangePhone(accountID int64, phone types.Phone) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	phone, err = NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+
+	if samePhone(account.Phone, phone) {
+		return nil
+	}
+
+	owner, err := s.accountByPhone(phone)
+	if err == nil && owner.ID != accountID && (owner.CustomerID == 0 || owner.CustomerID != account.CustomerID) {
+		return ErrPhoneRegistered
+	}
+
+	s.phoneHistory = append(s.phoneHistory, &types.PhoneChange{
+		AccountID: accountID,
+		OldPhone:  account.Phone,
+		NewPhone:  phone,
+		Changed:   s.now().Unix(),
+	})
+	account.Phone = phone
+
+	customer, err := s.FindCustomerByID(account.CustomerID)
+	if err == nil {
+		customer.Phone = phone
+		for _, acc := range s.accounts {
+			if acc.CustomerID == customer.ID {
+				acc.Phone = phone
+			}
+		}
+	}
+	return nil
+}
+
+// PhoneHistory - возвращает историю смены номеров аккаунта в порядке изменений.
+func (s *Service) PhoneHistory(accountID int64) ([]types.PhoneChange, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []types.PhoneChange{}
+	for _, change := range s.phoneHistory {
+		if change.AccountID == accountID {
+			history = append(history, *change)
+		}
+	}
+	return history, nil
+}
+
+// exportPhoneHistory сохраняет историю смены номеров в dir/phones.dump.
+func (s *Service) exportPhoneHistory(dir string) error {
+	if s.phoneHistory == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, change := range s.phoneHistory {
+		text := []byte(
+			strconv.FormatInt(change.AccountID, 10) + ";" +
+				escapeField(string(change.OldPhone)) + ";" +
+				escapeField(string(change.NewPhone)) + ";" +
+				strconv.FormatInt(change.Changed, 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "phones.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importPhoneHistory загружает историю смены номеров из dir/phones.dump, если файл есть.
+// Повторный импорт не дублирует уже известные записи.
+func (s *Service) importPhoneHistory(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "phones.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		accountID, _ := strconv.ParseInt(fields[0], 10, 64)
+		changed, _ := strconv.ParseInt(fields[3], 10, 64)
+		change := &types.PhoneChange{
+			AccountID: accountID,
+			OldPhone:  types.Phone(fields[1]),
+			NewPhone:  types.Phone(fields[2]),
+			Changed:   changed,
+		}
+
+		known := false
+		for _, existing := range s.phoneHistory {
+			if *existing == *change {
+				known = true
+				break
+			}
+		}
+		if !known {
+			s.phoneHistory = append(s.phoneHistory, change)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone types.Phone
+		want  types.Phone
+	}{
+		{phone: "+992000000001", want: "+992000000001"},
+		{phone: "992000000001", want: "+992000000001"},
+		{phone: "+992 000 000 001", want: "+992000000001"},
+		{phone: "00992-00-000-0001", want: "+992000000001"},
+		{phone: "+7 (912) 345-67-89", want: "+79123456789"},
+		{phone: "8 912 345 67 89", want: "+79123456789"},
+		{phone: "93 123 4567", want: "+992931234567"},
+		{phone: "+998 90 123 45 67", want: "+998901234567"},
+		{phone: "+49 30 1234567", want: "+49301234567"},
+	}
+
+	for _, test := range tests {
+		got, err := NormalizePhone(test.phone)
+		if err != nil {
+			t.Errorf("NormalizePhone(%q): error = %v", test.phone, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", test.phone, got, test.want)
+		}
+	}
+}
+
+func TestNormalizePhone_invalid(t *testing.T) {
+	phones := []types.Phone{"", "+992 00 000 01", "+7912345678", "+99890123456789", "+992abc000001", "12345"}
+
+	for _, phone := range phones {
+		_, err := NormalizePhone(phone)
+		var phoneErr *InvalidPhoneError
+		if !errors.As(err, &phoneErr) || !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("NormalizePhone(%q): must return InvalidPhoneError, returned: %v", phone, err)
+		}
+	}
+}
+
+func TestService_RegisterAccount_normalized(t *testing.T) {
+	s := newTestService()
+
+	account, err := s.RegisterAccount("+992 000 000 001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if account.Phone != "+992000000001" {
+		t.Errorf("RegisterAccount(): phone must be normalized, account = %v", account)
+		return
+	}
+
+	_, err = s.RegisterAccount("992000000001")
+	if err != ErrPhoneRegistered {
+		t.Errorf("RegisterAccount(): must return ErrPhoneRegistered, returned: %v", err)
+		return
+	}
+
+	_, err = s.RegisterAccount("0000")
+	if !errors.Is(err, ErrInvalidPhone) {
+		t.Errorf("RegisterAccount(): must return ErrInvalidPhone, returned: %v", err)
+		return
+	}
+}
+
+func TestService_CheckPhones(t *testing.T) {
+	dir := t.TempDir()
+	data := "1;+992000000001;100\n" +
+		"2;992 000 000 002;100\n" +
+		"3;+992 000 000 001;100\n" +
+		"4;phone;100\n" +
+		"5;+992000000002;100\n"
+	err := os.WriteFile(filepath.Join(dir, "accounts.dump"), []byte(data), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := newTestService()
+	err = s.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	account, err := s.FindAccountByID(2)
+	if err != nil || account.Phone != "+992000000002" {
+		t.Errorf("Import(): phone must be normalized, account = %v, error = %v", account, err)
+		return
+	}
+
+	report := s.CheckPhones()
+	want := PhoneReport{
+		Duplicates: []PhoneDuplicate{
+			{Phone: "+992000000001", AccountIDs: []int64{1, 3}},
+			{Phone: "+992000000002", AccountIDs: []int64{2, 5}},
+		},
+		Invalid: []int64{4},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CheckPhones(): wrong report = %v, want %v", report, want)
+		return
+	}
+}
+
+func TestService_FindAccountByPhone(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := s.FindAccountByPhone("992 000 000 001")
+	if err != nil {
+		t.Errorf("FindAccountByPhone(): error = %v", err)
+		return
+	}
+	if got != account {
+		t.Errorf("FindAccountByPhone(): wrong account = %v", got)
+		return
+	}
+
+	_, err = s.FindAccountByPhone("+992000000002")
+	if err != ErrAccountNotFound {
+		t.Errorf("FindAccountByPhone(): must return ErrAccountNotFound, returned: %v", err)
+		return
+	}
+}
+
+func TestService_ChangePhone(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.ChangePhone(account.ID, "992 000 000 002")
+	if err != ErrPhoneRegistered {
+		t.Errorf("ChangePhone(): must return ErrPhoneRegistered, returned: %v", err)
+		return
+	}
+
+	err = s.ChangePhone(account.ID, "+992 000 000 003")
+	if err != nil {
+		t.Errorf("ChangePhone(): error = %v", err)
+		return
+	}
+	if account.Phone != "+992000000003" {
+		t.Errorf("ChangePhone(): phone didn't change, account = %v", account)
+		return
+	}
+
+	_, err = s.FindAccountByPhone("+992000000001")
+	if err != ErrAccountNotFound {
+		t.Errorf("FindAccountByPhone(): old phone must be free, returned: %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := imported.FindAccountByPhone("+992000000003")
+	if err != nil || got.ID != account.ID {
+		t.Errorf("Import(): account must have new phone, account = %v, error = %v", got, err)
+		return
+	}
+
+	history, err := imported.PhoneHistory(account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(history) != 1 || history[0].OldPhone != "+992000000001" || history[0].NewPhone != "+992000000003" {
+		t.Errorf("PhoneHistory(): wrong history = %v", history)
+		return
+	}
+}
@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/Muhamadi02/wallet/pkg/qr"
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrQRAmountMismatch = errors.New("amount differs from qr payload amount")
+var ErrQRCurrency = errors.New("unsupported qr payload currency")
+
+// MerchantQR - формирует данные QR-кода для оплаты получателю в его категории.
+// Если amount равен нулю, сумму вводит плательщик. В reference можно передать
+// номер счёта на оплату - тогда оплата по коду погасит этот счёт.
+func (s *Service) MerchantQR(merchantID string, amount types.Money, reference string) (string, error) {
+	merchant, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return "", err
+	}
+
+	return qr.Encode(qr.Payload{
+		MerchantID:   merchant.ID,
+		MerchantName: merchant.Name,
+		Amount:       amount,
+		Category:     merchant.Category,
+		Reference:    reference,
+	})
+}
+
+// PayQR - оплачивает отсканированный QR-код. Для кода с суммой amount можно
+// не указывать (передать 0), для кода без суммы его вводит плательщик.
+func (s *Service) PayQR(accountID int64, data string, amount types.Money) (*types.Payment, error) {
+	payload, err := qr.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Currency != qr.DefaultCurrency {
+		return nil, ErrQRCurrency
+	}
+
+	merchant, err := s.FindMerchantByID(payload.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Amount != 0 {
+		if amount != 0 && amount != payload.Amount {
+			return nil, ErrQRAmountMismatch
+		}
+		amount = payload.Amount
+	}
+
+	opts := PaymentOptions{
+		MerchantID: merchant.ID,
+		Channel:    types.ChannelQR,
+	}
+	if payload.Reference != "" {
+		invoice := s.invoiceByID(payload.Reference)
+		if invoice != nil && invoice.MerchantID == merchant.ID {
+			opts.InvoiceID = invoice.ID
+		} else {
+			opts.Description = payload.Reference
+		}
+	}
+	return s.PayWithOptions(accountID, amount, payload.Category, opts)
+}
@@ -0,0 +1,120 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Muhamadi02/wallet/pkg/qr"
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_PayQR(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	settlement, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Аптека", "medicine", settlement.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	data, err := s.MerchantQR(merchant.ID, 150_00, "")
+	if err != nil {
+		t.Errorf("MerchantQR(): error = %v", err)
+		return
+	}
+
+	_, err = s.PayQR(account.ID, data, 100_00)
+	if err != ErrQRAmountMismatch {
+		t.Errorf("PayQR(): must return ErrQRAmountMismatch, returned: %v", err)
+		return
+	}
+	payment, err := s.PayQR(account.ID, data, 0)
+	if err != nil {
+		t.Errorf("PayQR(): error = %v", err)
+		return
+	}
+	if payment.Amount != 150_00 || payment.MerchantID != merchant.ID || payment.Category != "medicine" || payment.Channel != types.ChannelQR {
+		t.Errorf("PayQR(): wrong payment = %v", payment)
+		return
+	}
+	if settlement.Balance != 150_00 {
+		t.Errorf("PayQR(): merchant must be credited, settlement = %v", settlement)
+		return
+	}
+
+	// код без суммы
+	data, err = s.MerchantQR(merchant.ID, 0, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayQR(account.ID, data, 0)
+	if err != ErrAmountMustBePositive {
+		t.Errorf("PayQR(): must return ErrAmountMustBePositive, returned: %v", err)
+		return
+	}
+	payment, err = s.PayQR(account.ID, data, 20_00)
+	if err != nil || payment.Amount != 20_00 {
+		t.Errorf("PayQR(): wrong payment = %v, error = %v", payment, err)
+		return
+	}
+
+	unknown, err := qr.Encode(qr.Payload{MerchantID: "unknown", Amount: 1_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayQR(account.ID, unknown, 0)
+	if err != ErrMerchantNotFound {
+		t.Errorf("PayQR(): must return ErrMerchantNotFound, returned: %v", err)
+		return
+	}
+
+	_, err = s.PayQR(account.ID, data[:len(data)-1]+"0", 20_00)
+	if err != qr.ErrChecksum && err != qr.ErrInvalidPayload {
+		t.Errorf("PayQR(): must reject corrupted payload, returned: %v", err)
+		return
+	}
+}
+
+func TestService_PayQR_invoice(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Чайхана", "restaurant", account.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	invoice, err := s.IssueInvoice(merchant.ID, testInvoiceItems, s.now().AddDate(0, 0, 1))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	data, err := s.MerchantQR(merchant.ID, invoice.Amount, invoice.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.PayQR(account.ID, data, 0)
+	if err != nil {
+		t.Errorf("PayQR(): error = %v", err)
+		return
+	}
+	if payment.InvoiceID != invoice.ID || invoice.Status != types.InvoiceStatusPaid {
+		t.Errorf("PayQR(): invoice must be paid, payment = %v, invoice = %v", payment, invoice)
+		return
+	}
+}
@@ -0,0 +1,443 @@
+package wallet
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PaymentPredicate - условие отбора платежей для PaymentQuery. Условия
+// объединяются через And, Or и Not. Нулевое значение подходит к любому платежу.
+type PaymentPredicate struct {
+	match func(payment *types.Payment) bool
+	// accounts - счета, которыми ограничено условие; nil - любые счета.
+	// По ним запрос выбирает кандидатов из индекса, не просматривая все платежи.
+	accounts map[int64]bool
+}
+
+// ByAccount - платежи со счетов accountIDs.
+func ByAccount(accountIDs ...int64) PaymentPredicate {
+	accounts := make(map[int64]bool)
+	for _, accountID := range accountIDs {
+		accounts[accountID] = true
+	}
+	return PaymentPredicate{
+		match: func(payment *types.Payment) bool {
+			return accounts[payment.AccountID]
+		},
+		accounts: accounts,
+	}
+}
+
+// ByCategory - платежи в категориях categories (без вложенных; для них
+// используйте Where с NewCategoryFilter).
+func ByCategory(categories ...types.PaymentCategory) PaymentPredicate {
+	codes := make(map[types.PaymentCategory]bool)
+	for _, category := range categories {
+		codes[NormalizeCategory(category)] = true
+	}
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		return codes[NormalizeCategory(payment.Category)]
+	}}
+}
+
+// ByStatus - платежи в статусах statuses.
+func ByStatus(statuses ...types.PaymentStatus) PaymentPredicate {
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		for _, status := range statuses {
+			if payment.Status == status {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// ByAmount - платежи на сумму от min до max включительно; нулевой max - без
+// верхней границы.
+func ByAmount(min types.Money, max types.Money) PaymentPredicate {
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		return payment.Amount >= min && (max == 0 || payment.Amount <= max)
+	}}
+}
+
+// ByTime - платежи, созданные в промежутке [from, to); нулевая граница не ограничивает.
+func ByTime(from time.Time, to time.Time) PaymentPredicate {
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		if !from.IsZero() && payment.Created < from.Unix() {
+			return false
+		}
+		return to.IsZero() || payment.Created < to.Unix()
+	}}
+}
+
+// ByMerchant - платежи в пользу получателей merchantIDs.
+func ByMerchant(merchantIDs ...string) PaymentPredicate {
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		for _, merchantID := range merchantIDs {
+			if payment.MerchantID == merchantID {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// ByTag - платежи хотя бы с одной из меток tags (без учёта регистра).
+func ByTag(tags ...string) PaymentPredicate {
+	filters := []func(payment types.Payment) bool{}
+	for _, tag := range tags {
+		filters = append(filters, FilterTag(tag))
+	}
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		for _, filter := range filters {
+			if filter(*payment) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// Where - условие из функции-фильтра, например FilterMerchant или
+// NewCategoryFilter(...).Build().
+func Where(filter func(payment types.Payment) bool) PaymentPredicate {
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		return filter(*payment)
+	}}
+}
+
+// And - платежи, подходящие под все условия.
+func And(predicates ...PaymentPredicate) PaymentPredicate {
+	var accounts map[int64]bool
+	for _, predicate := range predicates {
+		accounts = intersectAccounts(accounts, predicate.accounts)
+	}
+	return PaymentPredicate{
+		match: func(payment *types.Payment) bool {
+			for _, predicate := range predicates {
+				if !predicate.matches(payment) {
+					return false
+				}
+			}
+			return true
+		},
+		accounts: accounts,
+	}
+}
+
+// Or - платежи, подходящие хотя бы под одно условие.
+func Or(predicates ...PaymentPredicate) PaymentPredicate {
+	accounts := make(map[int64]bool)
+	for _, predicate := range predicates {
+		if predicate.accounts == nil {
+			accounts = nil
+			break
+		}
+		for accountID := range predicate.accounts {
+			accounts[accountID] = true
+		}
+	}
+	return PaymentPredicate{
+		match: func(payment *types.Payment) bool {
+			for _, predicate := range predicates {
+				if predicate.matches(payment) {
+					return true
+				}
+			}
+			return false
+		},
+		accounts: accounts,
+	}
+}
+
+// Not - платежи, не подходящие под условие.
+func Not(predicate PaymentPredicate) PaymentPredicate {
+	return PaymentPredicate{match: func(payment *types.Payment) bool {
+		return !predicate.matches(payment)
+	}}
+}
+
+func (p PaymentPredicate) matches(payment *types.Payment) bool {
+	return p.match == nil || p.match(payment)
+}
+
+// intersectAccounts пересекает ограничения по счетам; nil означает "любые".
+func intersectAccounts(a map[int64]bool, b map[int64]bool) map[int64]bool {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	result := make(map[int64]bool)
+	for accountID := range a {
+		if b[accountID] {
+			result[accountID] = true
+		}
+	}
+	return result
+}
+
+// PaymentSortField - поле, по которому сортируются результаты запроса.
+type PaymentSortField int
+
+// Поля сортировки. При равных значениях платежи идут в порядке создания.
+const (
+	SortByCreated PaymentSortField = iota
+	SortByAmount
+)
+
+// PaymentQuery - запрос платежей: условие, сортировка и страница результатов.
+type PaymentQuery struct {
+	where      PaymentPredicate
+	sortBy     PaymentSortField
+	desc       bool
+	offset     int
+	limit      int
+	after      string
+	goroutines int
+}
+
+// PaymentPage - страница результатов запроса.
+type PaymentPage struct {
+	Payments   []types.Payment
+	Total      int    // сколько всего платежей подходит под условие
+	NextCursor string // курсор следующей страницы, пустой на последней странице
+}
+
+// NewPaymentQuery - создаёт запрос платежей, подходящих под все условия.
+func NewPaymentQuery(predicates ...PaymentPredicate) *PaymentQuery {
+	return &PaymentQuery{where: And(predicates...)}
+}
+
+// Where - добавляет условия, которые должны выполняться вместе с уже заданными.
+func (q *PaymentQuery) Where(predicates ...PaymentPredicate) *PaymentQuery {
+	q.where = And(append([]PaymentPredicate{q.where}, predicates...)...)
+	return q
+}
+
+// OrderBy - задаёт сортировку (по умолчанию - по времени создания по возрастанию).
+func (q *PaymentQuery) OrderBy(field PaymentSortField, desc bool) *PaymentQuery {
+	q.sortBy = field
+	q.desc = desc
+	return q
+}
+
+// Offset - пропускает первые n результатов.
+func (q *PaymentQuery) Offset(n int) *PaymentQuery {
+	q.offset = n
+	return q
+}
+
+// Limit - ограничивает страницу n платежами; 0 - без ограничения.
+func (q *PaymentQuery) Limit(n int) *PaymentQuery {
+	q.limit = n
+	return q
+}
+
+// After - начинает страницу после платежа, на котором закончилась страница
+// с курсором cursor (PaymentPage.NextCursor).
+func (q *PaymentQuery) After(cursor string) *PaymentQuery {
+	q.after = cursor
+	return q
+}
+
+// Parallel - проверяет условие в goroutines горутинах, как FilterPaymentsByFn.
+func (q *PaymentQuery) Parallel(goroutines int) *PaymentQuery {
+	q.goroutines = goroutines
+	return q
+}
+
+// QueryPayments - выполняет запрос платежей. Если условие ограничено
+// счетами (ByAccount), платежи выбираются по индексу счетов.
+func (s *Service) QueryPayments(q *PaymentQuery) (PaymentPage, error) {
+	if q == nil {
+		q = NewPaymentQuery()
+	}
+
+	indexes, total, more, err := s.queryPage(q)
+	if err != nil {
+		return PaymentPage{}, err
+	}
+
+	page := PaymentPage{
+		Payments: make([]types.Payment, 0, len(indexes)),
+		Total:    total,
+	}
+	for _, index := range indexes {
+		page.Payments = append(page.Payments, *s.payments[index])
+	}
+	if more {
+		last := indexes[len(indexes)-1]
+		page.NextCursor = encodeCursor(q.sortKey(s.payments[last]), last)
+	}
+	return page, nil
+}
+
+// queryPage возвращает номера платежей страницы в порядке запроса, общее
+// число подходящих платежей и признак того, что после страницы есть ещё.
+func (s *Service) queryPage(q *PaymentQuery) ([]int, int, bool, error) {
+	matched := s.matchPayments(q.candidates(s), q.where, q.goroutines)
+	sort.Slice(matched, func(i, j int) bool {
+		return q.less(s.payments[matched[i]], matched[i], s.payments[matched[j]], matched[j])
+	})
+
+	start := 0
+	if q.after != "" {
+		key, seq, err := decodeCursor(q.after)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		start = sort.Search(len(matched), func(i int) bool {
+			payment := s.payments[matched[i]]
+			return q.lessKey(key, seq, q.sortKey(payment), matched[i])
+		})
+	}
+	if q.offset > 0 {
+		start += q.offset
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := len(matched)
+	if q.limit > 0 && start+q.limit < end {
+		end = start + q.limit
+	}
+
+	return matched[start:end], len(matched), end < len(matched) && end > start, nil
+}
+
+// candidates возвращает номера платежей, которые нужно проверить условием.
+func (q *PaymentQuery) candidates(s *Service) []int {
+	if q.where.accounts == nil {
+		candidates := make([]int, len(s.payments))
+		for i := range candidates {
+			candidates[i] = i
+		}
+		return candidates
+	}
+
+	index := s.paymentsByAccount()
+	candidates := []int{}
+	for accountID := range q.where.accounts {
+		candidates = append(candidates, index[accountID]...)
+	}
+	return candidates
+}
+
+// matchPayments проверяет кандидатов условием в нескольких горутинах.
+func (s *Service) matchPayments(candidates []int, where PaymentPredicate, goroutines int) []int {
+	if goroutines < 1 {
+		goroutines = 1
+	}
+
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	num := len(candidates)/goroutines + 1
+	matched := []int{}
+
+	for i := 0; i < goroutines; i++ {
+		low := i * num
+		if low >= len(candidates) {
+			break
+		}
+		high := low + num
+		if high > len(candidates) {
+			high = len(candidates)
+		}
+
+		wg.Add(1)
+		go func(part []int) {
+			defer wg.Done()
+			found := []int{}
+			for _, index := range part {
+				if where.matches(s.payments[index]) {
+					found = append(found, index)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			matched = append(matched, found...)
+		}(candidates[low:high])
+	}
+
+	wg.Wait()
+	return matched
+}
+
+// paymentsByAccount дополняет индекс платежей по счетам новыми платежами
+// (платежи только добавляются) и возвращает его.
+func (s *Service) paymentsByAccount() map[int64][]int {
+	if s.accountIndex == nil {
+		s.accountIndex = make(map[int64][]int)
+		s.accountIndexed = 0
+	}
+	for ; s.accountIndexed < len(s.payments); s.accountIndexed++ {
+		payment := s.payments[s.accountIndexed]
+		s.accountIndex[payment.AccountID] = append(s.accountIndex[payment.AccountID], s.accountIndexed)
+	}
+	return s.accountIndex
+}
+
+func (q *PaymentQuery) sortKey(payment *types.Payment) int64 {
+	if q.sortBy == SortByAmount {
+		return int64(payment.Amount)
+	}
+	return payment.Created
+}
+
+// less сравнивает платежи a и b с номерами ia и ib в порядке запроса.
+func (q *PaymentQuery) less(a *types.Payment, ia int, b *types.Payment, ib int) bool {
+	return q.lessKey(q.sortKey(a), ia, q.sortKey(b), ib)
+}
+
+func (q *PaymentQuery) lessKey(keyA int64, seqA int, keyB int64, seqB int) bool {
+	if keyA != keyB {
+		return (keyA < keyB) != q.desc
+	}
+	if seqA == seqB {
+		return false
+	}
+	return (seqA < seqB) != q.desc
+}
+
+// encodeCursor кодирует позицию платежа в порядке сортировки.
+func encodeCursor(key int64, seq int) string {
+	raw := strconv.FormatInt(key, 10) + ":" + strconv.Itoa(seq)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (int64, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	return parsePosition(string(raw))
+}
+
+// parsePosition разбирает позицию платежа вида "ключ:номер".
+func parsePosition(raw string) (int64, int, error) {
+	keyStr, seqStr, ok := strings.Cut(raw, ":")
+	if !ok {
+		return 0, 0, ErrInvalidCursor
+	}
+	key, err := strconv.ParseInt(keyStr, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	seq, err := strconv.Atoi(seqStr)
+	if err != nil || seq < 0 {
+		return 0, 0, ErrInvalidCursor
+	}
+	return key, seq, nil
+}
@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+// addQueryPayments создаёт два счёта с платежами на разные суммы, по одному в час.
+func (s *testService) addQueryPayments() (*types.Account, *types.Account, error) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	first, err := s.addAccountWithBalance("+992000000001", 10_000_00)
+	if err != nil {
+		return nil, nil, err
+	}
+	second, err := s.addAccountWithBalance("+992000000002", 10_000_00)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payments := []struct {
+		account  *types.Account
+		amount   types.Money
+		category types.PaymentCategory
+		tags     []string
+	}{
+		{first, 300_00, "auto", []string{"trip"}},
+		{second, 100_00, "medicine", nil},
+		{first, 200_00, "fuel", []string{"trip", "work"}},
+		{second, 500_00, "auto", []string{"work"}},
+		{first, 100_00, "medicine", nil},
+	}
+	for _, p := range payments {
+		now = now.Add(time.Hour)
+		_, err = s.PayWithOptions(p.account.ID, p.amount, p.category, PaymentOptions{Tags: p.tags})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return first, second, nil
+}
+
+func paymentAmounts(payments []types.Payment) []types.Money {
+	amounts := []types.Money{}
+	for _, payment := range payments {
+		amounts = append(amounts, payment.Amount)
+	}
+	return amounts
+}
+
+func TestService_QueryPayments(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	first, second, err := s.addQueryPayments()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(s.payments[2].ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	from := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query *PaymentQuery
+		want  []types.Money
+	}{
+		{"account", NewPaymentQuery(ByAccount(first.ID)), []types.Money{300_00, 200_00, 100_00}},
+		{"category", NewPaymentQuery(ByCategory("AUTO")), []types.Money{300_00, 500_00}},
+		{"status", NewPaymentQuery(Not(ByStatus(types.PaymentStatusFail))), []types.Money{300_00, 100_00, 500_00, 100_00}},
+		{"amount", NewPaymentQuery(ByAmount(150_00, 300_00)), []types.Money{300_00, 200_00}},
+		{"time", NewPaymentQuery(ByTime(from, from.Add(2*time.Hour))), []types.Money{100_00, 200_00}},
+		{"tag", NewPaymentQuery(ByTag("WORK")), []types.Money{200_00, 500_00}},
+		{"and", NewPaymentQuery(ByAccount(first.ID), ByTag("trip")), []types.Money{300_00, 200_00}},
+		{"or", NewPaymentQuery(Or(ByAccount(second.ID), ByCategory("fuel"))), []types.Money{100_00, 200_00, 500_00}},
+		{"or with index", NewPaymentQuery(ByAccount(first.ID)).Where(Or(ByAccount(second.ID), ByAmount(0, 100_00))), []types.Money{100_00}},
+		{"where", NewPaymentQuery(Where(NewCategoryFilter("auto").WithSubcategories(s.Service).Build())), []types.Money{300_00, 200_00, 500_00}},
+		{"sort", NewPaymentQuery().OrderBy(SortByAmount, true), []types.Money{500_00, 300_00, 200_00, 100_00, 100_00}},
+		{"page", NewPaymentQuery().OrderBy(SortByCreated, true).Offset(1).Limit(2), []types.Money{500_00, 200_00}},
+	}
+	for _, tt := range tests {
+		page, err := s.QueryPayments(tt.query)
+		if err != nil {
+			t.Errorf("QueryPayments(): %s: error = %v", tt.name, err)
+			return
+		}
+		if !reflect.DeepEqual(paymentAmounts(page.Payments), tt.want) {
+			t.Errorf("QueryPayments(): %s: got = %v, want = %v", tt.name, paymentAmounts(page.Payments), tt.want)
+			return
+		}
+	}
+}
+
+func TestService_QueryPayments_cursor(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, _, err = s.addQueryPayments()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	query := NewPaymentQuery().OrderBy(SortByAmount, false).Limit(2)
+	got := []types.Money{}
+	for i := 0; i < 5; i++ {
+		page, err := s.QueryPayments(query)
+		if err != nil {
+			t.Errorf("QueryPayments(): error = %v", err)
+			return
+		}
+		if page.Total != 5 && page.Total != 6 {
+			t.Errorf("QueryPayments(): wrong total = %v", page.Total)
+			return
+		}
+		got = append(got, paymentAmounts(page.Payments)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.After(page.NextCursor)
+
+		if i == 0 {
+			// новый платёж после курсора попадает на следующие страницы
+			_, err = s.Pay(s.payments[0].AccountID, 400_00, "auto")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}
+	want := []types.Money{100_00, 100_00, 200_00, 300_00, 400_00, 500_00}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QueryPayments(): got = %v, want = %v", got, want)
+		return
+	}
+
+	_, err = s.QueryPayments(NewPaymentQuery().After("not a cursor"))
+	if err != ErrInvalidCursor {
+		t.Errorf("QueryPayments(): must return ErrInvalidCursor, returned: %v", err)
+		return
+	}
+}
+
+func TestService_QueryPayments_parallel(t *testing.T) {
+	s := newTestService()
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	first, _, err := s.addQueryPayments()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, goroutines := range []int{0, 1, 2, 3, 10} {
+		page, err := s.QueryPayments(NewPaymentQuery(Or(ByAccount(first.ID), ByTag("work"))).Parallel(goroutines))
+		if err != nil {
+			t.Errorf("QueryPayments(): error = %v", err)
+			return
+		}
+		want := []types.Money{300_00, 200_00, 500_00, 100_00}
+		if !reflect.DeepEqual(paymentAmounts(page.Payments), want) {
+			t.Errorf("QueryPayments(): goroutines = %v, got = %v", goroutines, paymentAmounts(page.Payments))
+			return
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrMoneyRequestNotFound = errors.New("money request not found")
+var ErrMoneyRequestNotPending = errors.New("money request is not pending")
+var ErrMoneyRequestExpired = errors.New("money request is expired")
+var ErrInvalidShares = errors.New("invalid split shares")
+
+// DefaultMoneyRequestTTL - время жизни запроса денег, если оно не задано явно.
+const DefaultMoneyRequestTTL = 7 * 24 * time.Hour
+
+// SplitShare - доля участника в разделённом платеже.
+type SplitShare struct {
+	Phone  types.Phone
+	Amount types.Money
+}
+
+// RequestMoney - запрашивает amount у владельца номера payerPhone. Деньги
+// придут на requesterID, когда плательщик примет запрос.
+func (s *Service) RequestMoney(requesterID int64, payerPhone types.Phone, amount types.Money, note string, ttl time.Duration) (*types.MoneyRequest, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	requester, err := s.FindAccountByID(requesterID)
+	if err != nil {
+		return nil, err
+	}
+	payer, err := s.requestPayer(requester, payerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newMoneyRequest(requester, payer, amount, note, ttl, ""), nil
+}
+
+// SplitPayment - делит платёж поровну между его автором и владельцами номеров
+// phones и отправляет им запросы на их доли. Остаток от деления остаётся на
+// авторе платежа.
+func (s *Service) SplitPayment(paymentID string, phones []types.Phone, note string) ([]types.MoneyRequest, error) {
+	payment, err := s.FindPaymentById(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(phones) == 0 {
+		return nil, ErrInvalidShares
+	}
+
+	share := payment.Amount / types.Money(len(phones)+1)
+	shares := []SplitShare{}
+	for _, phone := range phones {
+		shares = append(shares, SplitShare{Phone: phone, Amount: share})
+	}
+	return s.SplitPaymentByShares(paymentID, shares, note)
+}
+
+// SplitPaymentByShares - отправляет участникам платежа запросы на заданные
+// доли. Сумма долей вместе с уже запрошенными по платежу (кроме отклонённых
+// и просроченных запросов) не может превышать сумму платежа; запросы
+// создаются, только если все участники найдены.
+func (s *Service) SplitPaymentByShares(paymentID string, shares []SplitShare, note string) ([]types.MoneyRequest, error) {
+	payment, err := s.FindPaymentById(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == types.PaymentStatusFail {
+		return nil, ErrPaymentRejected
+	}
+	if len(shares) == 0 {
+		return nil, ErrInvalidShares
+	}
+
+	requester, err := s.FindAccountByID(payment.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	payers := []*types.Account{}
+	var total types.Money
+	for _, share := range shares {
+		if share.Amount <= 0 {
+			return nil, ErrInvalidShares
+		}
+		total += share.Amount
+
+		payer, err := s.requestPayer(requester, share.Phone)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range payers {
+			if other.ID == payer.ID {
+				return nil, ErrInvalidShares
+			}
+		}
+		payers = append(payers, payer)
+	}
+	if total+s.splitAmount(payment.ID) > payment.Amount {
+		return nil, ErrInvalidShares
+	}
+
+	requests := []types.MoneyRequest{}
+	for i, payer := range payers {
+		request := s.newMoneyRequest(requester, payer, shares[i].Amount, note, 0, payment.ID)
+		requests = append(requests, *request)
+	}
+	return requests, nil
+}
+
+// splitAmount возвращает сумму действующих и принятых запросов по платежу.
+func (s *Service) splitAmount(paymentID string) types.Money {
+	s.ExpireMoneyRequests()
+
+	var total types.Money
+	for _, request := range s.moneyRequests {
+		if request.PaymentID != paymentID {
+			continue
+		}
+		switch request.Status {
+		case types.MoneyRequestPending, types.MoneyRequestAccepted:
+			total += request.Amount
+		}
+	}
+	return total
+}
+
+// FindMoneyRequestByID - поиск запроса денег по идентификатору.
+func (s *Service) FindMoneyRequestByID(requestID string) (*types.MoneyRequest, error) {
+	for _, request := range s.moneyRequests {
+		if request.ID == requestID {
+			return request, nil
+		}
+	}
+
+	return nil, ErrMoneyRequestNotFound
+}
+
+// AcceptMoneyRequest - принимает запрос: деньги переводятся со счёта
+// плательщика на счёт автора запроса. Если перевод не удался, запрос
+// остаётся в ожидании.
+func (s *Service) AcceptMoneyRequest(requestID string) error {
+	request, err := s.pendingMoneyRequest(requestID)
+	if err != nil {
+		return err
+	}
+
+	err = s.Transfer(request.PayerID, request.RequesterID, request.Amount)
+	if err != nil {
+		return err
+	}
+
+	request.Status = types.MoneyRequestAccepted
+	return nil
+}
+
+// DeclineMoneyRequest - отклоняет запрос денег.
+func (s *Service) DeclineMoneyRequest(requestID string) error {
+	request, err := s.pendingMoneyRequest(requestID)
+	if err != nil {
+		return err
+	}
+
+	request.Status = types.MoneyRequestDeclined
+	return nil
+}
+
+// ExpireMoneyRequests - помечает просроченными все запросы, время жизни
+// которых истекло, и возвращает их количество.
+func (s *Service) ExpireMoneyRequests() int {
+	now := s.now().Unix()
+	count := 0
+	for _, request := range s.moneyRequests {
+		if request.Status != types.MoneyRequestPending || request.Expires > now {
+			continue
+		}
+		request.Status = types.MoneyRequestExpired
+		count++
+	}
+	return count
+}
+
+// IncomingMoneyRequests - возвращает запросы, адресованные счёту.
+func (s *Service) IncomingMoneyRequests(accountID int64) ([]types.MoneyRequest, error) {
+	return s.accountMoneyRequests(accountID, func(request *types.MoneyRequest) bool {
+		return request.PayerID == accountID
+	})
+}
+
+// OutgoingMoneyRequests - возвращает запросы, созданные счётом.
+func (s *Service) OutgoingMoneyRequests(accountID int64) ([]types.MoneyRequest, error) {
+	return s.accountMoneyRequests(accountID, func(request *types.MoneyRequest) bool {
+		return request.RequesterID == accountID
+	})
+}
+
+// accountMoneyRequests возвращает запросы счёта, подходящие под match.
+func (s *Service) accountMoneyRequests(accountID int64, match func(request *types.MoneyRequest) bool) ([]types.MoneyRequest, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireMoneyRequests()
+	requests := []types.MoneyRequest{}
+	for _, request := range s.moneyRequests {
+		if match(request) {
+			requests = append(requests, *request)
+		}
+	}
+	return requests, nil
+}
+
+// pendingMoneyRequest возвращает запрос, который ещё можно принять или отклонить.
+func (s *Service) pendingMoneyRequest(requestID string) (*types.MoneyRequest, error) {
+	request, err := s.FindMoneyRequestByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireMoneyRequests()
+	switch request.Status {
+	case types.MoneyRequestPending:
+		return request, nil
+	case types.MoneyRequestExpired:
+		return nil, ErrMoneyRequestExpired
+	default:
+		return nil, ErrMoneyRequestNotPending
+	}
+}
+
+// requestPayer находит основной счёт владельца номера phone.
+func (s *Service) requestPayer(requester *types.Account, phone types.Phone) (*types.Account, error) {
+	payer, err := s.FindAccountByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	if payer.ID == requester.ID {
+		return nil, ErrSameAccount
+	}
+	return payer, nil
+}
+
+// newMoneyRequest создаёт запрос денег в статусе ожидания.
+func (s *Service) newMoneyRequest(requester *types.Account, payer *types.Account, amount types.Money, note string, ttl time.Duration, paymentID string) *types.MoneyRequest {
+	if ttl <= 0 {
+		ttl = DefaultMoneyRequestTTL
+	}
+
+	now := s.now()
+	request := &types.MoneyRequest{
+		ID:          uuid.New().String(),
+		RequesterID: requester.ID,
+		PayerID:     payer.ID,
+		Amount:      amount,
+		Note:        note,
+		Status:      types.MoneyRequestPending,
+		Created:     now.Unix(),
+		Expires:     now.Add(ttl).Unix(),
+		PaymentID:   paymentID,
+	}
+	s.moneyRequests = append(s.moneyRequests, request)
+	return request
+}
+
+// exportMoneyRequests сохраняет запросы денег в dir/requests.dump.
+func (s *Service) exportMoneyRequests(dir string) error {
+	if s.moneyRequests == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, request := range s.moneyRequests {
+		text := []byte(
+			request.ID + ";" +
+				strconv.FormatInt(request.RequesterID, 10) + ";" +
+				strconv.FormatInt(request.PayerID, 10) + ";" +
+				strconv.FormatInt(int64(request.Amount), 10) + ";" +
+				escapeField(request.Note) + ";" +
+				string(request.Status) + ";" +
+				strconv.FormatInt(request.Created, 10) + ";" +
+				strconv.FormatInt(request.Expires, 10) + ";" +
+				request.PaymentID + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "requests.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importMoneyRequests загружает запросы денег из dir/requests.dump, если файл есть.
+func (s *Service) importMoneyRequests(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "requests.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 9 {
+			continue
+		}
+
+		requesterID, _ := strconv.ParseInt(fields[1], 10, 64)
+		payerID, _ := strconv.ParseInt(fields[2], 10, 64)
+		amount, _ := strconv.ParseInt(fields[3], 10, 64)
+		created, _ := strconv.ParseInt(fields[6], 10, 64)
+		expires, _ := strconv.ParseInt(fields[7], 10, 64)
+
+		request, err := s.FindMoneyRequestByID(fields[0])
+		if err != nil {
+			request = &types.MoneyRequest{ID: fields[0]}
+			s.moneyRequests = append(s.moneyRequests, request)
+		}
+		request.RequesterID = requesterID
+		request.PayerID = payerID
+		request.Amount = types.Money(amount)
+		request.Note = fields[4]
+		request.Status = types.MoneyRequestStatus(fields[5])
+		request.Created = created
+		request.Expires = expires
+		request.PaymentID = fields[8]
+	}
+}
@@ -0,0 +1,231 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_RequestMoney(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	requester, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payer, err := s.addAccountWithBalance("+992000000002", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.RequestMoney(requester.ID, "+992000000001", 100_00, "", 0)
+	if err != ErrSameAccount {
+		t.Errorf("RequestMoney(): must return ErrSameAccount, returned: %v", err)
+		return
+	}
+
+	request, err := s.RequestMoney(requester.ID, "992 00 000 0002", 100_00, "обед", time.Hour)
+	if err != nil {
+		t.Errorf("RequestMoney(): error = %v", err)
+		return
+	}
+	if request.PayerID != payer.ID || request.Status != types.MoneyRequestPending {
+		t.Errorf("RequestMoney(): wrong request = %v", request)
+		return
+	}
+
+	incoming, err := s.IncomingMoneyRequests(payer.ID)
+	if err != nil || len(incoming) != 1 || incoming[0].ID != request.ID {
+		t.Errorf("IncomingMoneyRequests(): wrong requests = %v, error = %v", incoming, err)
+		return
+	}
+
+	err = s.AcceptMoneyRequest(request.ID)
+	if err != nil {
+		t.Errorf("AcceptMoneyRequest(): error = %v", err)
+		return
+	}
+	if requester.Balance != 100_00 || payer.Balance != 900_00 || request.Status != types.MoneyRequestAccepted {
+		t.Errorf("AcceptMoneyRequest(): wrong result, requester = %v, payer = %v, request = %v", requester, payer, request)
+		return
+	}
+	err = s.DeclineMoneyRequest(request.ID)
+	if err != ErrMoneyRequestNotPending {
+		t.Errorf("DeclineMoneyRequest(): must return ErrMoneyRequestNotPending, returned: %v", err)
+		return
+	}
+
+	declined, err := s.RequestMoney(requester.ID, "+992000000002", 100_00, "", 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.DeclineMoneyRequest(declined.ID)
+	if err != nil || declined.Status != types.MoneyRequestDeclined {
+		t.Errorf("DeclineMoneyRequest(): wrong request = %v, error = %v", declined, err)
+		return
+	}
+
+	expired, err := s.RequestMoney(requester.ID, "+992000000002", 100_00, "", time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = now.Add(time.Hour)
+	err = s.AcceptMoneyRequest(expired.ID)
+	if err != ErrMoneyRequestExpired || expired.Status != types.MoneyRequestExpired {
+		t.Errorf("AcceptMoneyRequest(): must return ErrMoneyRequestExpired, returned: %v, request = %v", err, expired)
+		return
+	}
+}
+
+func TestService_SplitPayment(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, phone := range []types.Phone{"+992000000002", "+992000000003"} {
+		_, err = s.addAccountWithBalance(phone, 1_000_00)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	payment, err := s.Pay(account.ID, 100_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	requests, err := s.SplitPayment(payment.ID, []types.Phone{"+992000000002", "+992000000003"}, "ужин")
+	if err != nil {
+		t.Errorf("SplitPayment(): error = %v", err)
+		return
+	}
+	if len(requests) != 2 || requests[0].Amount != 33_33 || requests[1].Amount != 33_33 || requests[0].PaymentID != payment.ID {
+		t.Errorf("SplitPayment(): wrong requests = %v", requests)
+		return
+	}
+
+	_, err = s.SplitPaymentByShares(payment.ID, []SplitShare{
+		{Phone: "+992000000002", Amount: 60_00},
+		{Phone: "+992000000003", Amount: 40_01},
+	}, "")
+	if err != ErrInvalidShares {
+		t.Errorf("SplitPaymentByShares(): must return ErrInvalidShares, returned: %v", err)
+		return
+	}
+	_, err = s.SplitPaymentByShares(payment.ID, []SplitShare{{Phone: "+992000000009", Amount: 10_00}}, "")
+	if err != ErrAccountNotFound {
+		t.Errorf("SplitPaymentByShares(): must return ErrAccountNotFound, returned: %v", err)
+		return
+	}
+
+	// уже запрошенные 66.66 тоже учитываются
+	_, err = s.SplitPaymentByShares(payment.ID, []SplitShare{{Phone: "+992000000002", Amount: 33_35}}, "")
+	if err != ErrInvalidShares {
+		t.Errorf("SplitPaymentByShares(): must return ErrInvalidShares, returned: %v", err)
+		return
+	}
+	for _, request := range requests {
+		err = s.DeclineMoneyRequest(request.ID)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	requests, err = s.SplitPaymentByShares(payment.ID, []SplitShare{
+		{Phone: "+992000000002", Amount: 70_00},
+		{Phone: "+992000000003", Amount: 10_00},
+	}, "")
+	if err != nil || len(requests) != 2 || requests[0].Amount != 70_00 {
+		t.Errorf("SplitPaymentByShares(): wrong requests = %v, error = %v", requests, err)
+		return
+	}
+
+	outgoing, err := s.OutgoingMoneyRequests(account.ID)
+	if err != nil || len(outgoing) != 4 {
+		t.Errorf("OutgoingMoneyRequests(): wrong requests = %v, error = %v", outgoing, err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindMoneyRequestByID(outgoing[0].ID)
+	if err != nil || got.Note != "ужин" || got.PaymentID != payment.ID {
+		t.Errorf("Import(): requests must be persisted, request = %v, error = %v", got, err)
+		return
+	}
+}
+
+func TestService_SplitPaymentByShares_repeated(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.addAccountWithBalance("+992000000002", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 100_00, "restaurant")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	shares := []SplitShare{{Phone: "+992000000002", Amount: 90_00}}
+	requests, err := s.SplitPaymentByShares(payment.ID, shares, "")
+	if err != nil {
+		t.Errorf("SplitPaymentByShares(): error = %v", err)
+		return
+	}
+	err = s.AcceptMoneyRequest(requests[0].ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.SplitPaymentByShares(payment.ID, shares, "")
+	if err != ErrInvalidShares {
+		t.Errorf("SplitPaymentByShares(): must return ErrInvalidShares, returned: %v", err)
+		return
+	}
+
+	// просроченный запрос не учитывается
+	shares[0].Amount = 10_00
+	requests, err = s.SplitPaymentByShares(payment.ID, shares, "")
+	if err != nil {
+		t.Errorf("SplitPaymentByShares(): error = %v", err)
+		return
+	}
+	now = time.Unix(requests[0].Expires, 0)
+	_, err = s.SplitPaymentByShares(payment.ID, shares, "")
+	if err != nil {
+		t.Errorf("SplitPaymentByShares(): expired request must not count, error = %v", err)
+		return
+	}
+}
@@ -0,0 +1,265 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrPaymentNotInProgress = errors.New("payment is not in progress")
+var ErrInvalidCashbackRate = errors.New("invalid cashback rate")
+var ErrRewardNotFound = errors.New("reward not found")
+
+// DefaultRewardHoldingPeriod - сколько кэшбэк ждёт зачисления, если период не задан.
+const DefaultRewardHoldingPeriod = 14 * 24 * time.Hour
+
+// RewardTotals - суммы кэшбэка по счёту.
+type RewardTotals struct {
+	Pending    types.Money // ждёт зачисления
+	Credited   types.Money // зачислен и не списан
+	ClawedBack types.Money // списан после отклонения платежа
+}
+
+// SetCashback - задаёт ставку кэшбэка для категории в базисных пунктах
+// (200 = 2%). Ставка действует и на вложенные категории, если для них не задана своя.
+// Нулевая ставка отключает кэшбэк для категории.
+func (s *Service) SetCashback(category types.PaymentCategory, rate int64) error {
+	if rate < 0 || rate > 10000 {
+		return ErrInvalidCashbackRate
+	}
+
+	category = NormalizeCategory(category)
+	if rate == 0 {
+		delete(s.cashbackRates, category)
+		return nil
+	}
+
+	if s.cashbackRates == nil {
+		s.cashbackRates = make(map[types.PaymentCategory]int64)
+	}
+	s.cashbackRates[category] = rate
+	return nil
+}
+
+// SetRewardHoldingPeriod - задаёт, сколько кэшбэк ждёт зачисления после
+// подтверждения платежа.
+func (s *Service) SetRewardHoldingPeriod(period time.Duration) {
+	s.rewardHoldingPeriod = &period
+}
+
+// ConfirmPayment - подтверждает платёж и начисляет за него кэшбэк, который
+// будет зачислен на счёт после периода удержания (см. ProcessRewards).
+// Если кэшбэк за платёж не положен, возвращается nil.
+func (s *Service) ConfirmPayment(paymentID string) (*types.Reward, error) {
+	payment, err := s.FindPaymentById(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != types.PaymentStatusInProgress {
+		return nil, ErrPaymentNotInProgress
+	}
+
+	payment.Status = types.PaymentStatusOk
+
+	amount := types.Money(int64(payment.Amount) * s.cashbackRate(payment.Category) / 10000)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	period := DefaultRewardHoldingPeriod
+	if s.rewardHoldingPeriod != nil {
+		period = *s.rewardHoldingPeriod
+	}
+
+	now := s.now()
+	reward := &types.Reward{
+		ID:        uuid.New().String(),
+		AccountID: payment.AccountID,
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Status:    types.RewardStatusPending,
+		Created:   now.Unix(),
+		CreditAt:  now.Add(period).Unix(),
+	}
+	s.rewards = append(s.rewards, reward)
+	return reward, nil
+}
+
+// ProcessRewards - зачисляет кэшбэк, период удержания которого истёк, и
+// возвращает зачисленные награды. Кэшбэк на счёт, закрытый для зачислений,
+// остаётся в ожидании, а на закрытый счёт - отменяется.
+func (s *Service) ProcessRewards() []types.Reward {
+	now := s.now()
+
+	credited := []types.Reward{}
+	for _, reward := range s.rewards {
+		if reward.Status != types.RewardStatusPending || reward.CreditAt > now.Unix() {
+			continue
+		}
+
+		account, err := s.FindAccountByID(reward.AccountID)
+		if err != nil {
+			continue
+		}
+		err = checkCredit(account)
+		if err == ErrAccountClosed {
+			reward.Status = types.RewardStatusCancelled
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		account.Balance += reward.Amount
+		reward.Status = types.RewardStatusCredited
+		s.addLedgerEntry(account.ID, reward.Amount, types.LedgerCashback, reward.PaymentID, now)
+		credited = append(credited, *reward)
+	}
+	return credited
+}
+
+// FindRewardByID - поиск кэшбэка по идентификатору.
+func (s *Service) FindRewardByID(rewardID string) (*types.Reward, error) {
+	for _, reward := range s.rewards {
+		if reward.ID == rewardID {
+			return reward, nil
+		}
+	}
+
+	return nil, ErrRewardNotFound
+}
+
+// RewardHistory - возвращает весь кэшбэк счёта в порядке начисления.
+func (s *Service) RewardHistory(accountID int64) ([]types.Reward, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := []types.Reward{}
+	for _, reward := range s.rewards {
+		if reward.AccountID == accountID {
+			rewards = append(rewards, *reward)
+		}
+	}
+	return rewards, nil
+}
+
+// RewardTotals - возвращает суммы кэшбэка счёта по статусам.
+func (s *Service) RewardTotals(accountID int64) (RewardTotals, error) {
+	rewards, err := s.RewardHistory(accountID)
+	if err != nil {
+		return RewardTotals{}, err
+	}
+
+	totals := RewardTotals{}
+	for _, reward := range rewards {
+		switch reward.Status {
+		case types.RewardStatusPending:
+			totals.Pending += reward.Amount
+		case types.RewardStatusCredited:
+			totals.Credited += reward.Amount
+		case types.RewardStatusClawedBack:
+			totals.ClawedBack += reward.Amount
+		}
+	}
+	return totals, nil
+}
+
+// cashbackRate возвращает ставку кэшбэка категории или ближайшей родительской.
+func (s *Service) cashbackRate(category types.PaymentCategory) int64 {
+	for _, code := range s.categoryPath(category) {
+		rate, ok := s.cashbackRates[code]
+		if ok {
+			return rate
+		}
+	}
+	return 0
+}
+
+// clawBackReward отменяет кэшбэк отклонённого платежа, а уже зачисленный
+// списывает со счёта (баланс при этом может стать отрицательным).
+func (s *Service) clawBackReward(payment *types.Payment, account *types.Account) {
+	for _, reward := range s.rewards {
+		if reward.PaymentID != payment.ID {
+			continue
+		}
+
+		switch reward.Status {
+		case types.RewardStatusPending:
+			reward.Status = types.RewardStatusCancelled
+		case types.RewardStatusCredited:
+			account.Balance -= reward.Amount
+			reward.Status = types.RewardStatusClawedBack
+			s.addLedgerEntry(account.ID, -reward.Amount, types.LedgerCashback, payment.ID, s.now())
+		}
+	}
+}
+
+// exportRewards сохраняет кэшбэк в dir/rewards.dump.
+func (s *Service) exportRewards(dir string) error {
+	if s.rewards == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, reward := range s.rewards {
+		text := []byte(
+			reward.ID + ";" +
+				strconv.FormatInt(reward.AccountID, 10) + ";" +
+				reward.PaymentID + ";" +
+				strconv.FormatInt(int64(reward.Amount), 10) + ";" +
+				string(reward.Status) + ";" +
+				strconv.FormatInt(reward.Created, 10) + ";" +
+				strconv.FormatInt(reward.CreditAt, 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "rewards.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importRewards загружает кэшбэк из dir/rewards.dump, если файл есть.
+func (s *Service) importRewards(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "rewards.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 7 {
+			continue
+		}
+
+		accountID, _ := strconv.ParseInt(fields[1], 10, 64)
+		amount, _ := strconv.ParseInt(fields[3], 10, 64)
+		created, _ := strconv.ParseInt(fields[5], 10, 64)
+		creditAt, _ := strconv.ParseInt(fields[6], 10, 64)
+
+		reward, err := s.FindRewardByID(fields[0])
+		if err != nil {
+			reward = &types.Reward{ID: fields[0]}
+			s.rewards = append(s.rewards, reward)
+		}
+		reward.AccountID = accountID
+		reward.PaymentID = fields[2]
+		reward.Amount = types.Money(amount)
+		reward.Status = types.RewardStatus(fields[4])
+		reward.Created = created
+		reward.CreditAt = creditAt
+	}
+}
@@ -0,0 +1,172 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_ConfirmPayment_cashback(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+	s.SetRewardHoldingPeriod(48 * time.Hour)
+
+	err := s.addCategories()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetCashback("auto", 200)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetCashback("auto", 10001)
+	if err != ErrInvalidCashbackRate {
+		t.Errorf("SetCashback(): must return ErrInvalidCashbackRate, returned: %v", err)
+		return
+	}
+
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// ставка родительской категории действует на вложенную
+	payment, err := s.Pay(account.ID, 100_00, "fuel")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reward, err := s.ConfirmPayment(payment.ID)
+	if err != nil {
+		t.Errorf("ConfirmPayment(): error = %v", err)
+		return
+	}
+	if reward == nil || reward.Amount != 2_00 || reward.Status != types.RewardStatusPending || payment.Status != types.PaymentStatusOk {
+		t.Errorf("ConfirmPayment(): wrong reward = %v, payment = %v", reward, payment)
+		return
+	}
+	_, err = s.ConfirmPayment(payment.ID)
+	if err != ErrPaymentNotInProgress {
+		t.Errorf("ConfirmPayment(): must return ErrPaymentNotInProgress, returned: %v", err)
+		return
+	}
+
+	// категория без кэшбэка
+	other, err := s.Pay(account.ID, 100_00, "medicine")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	none, err := s.ConfirmPayment(other.ID)
+	if err != nil || none != nil {
+		t.Errorf("ConfirmPayment(): must return no reward, reward = %v, error = %v", none, err)
+		return
+	}
+
+	credited := s.ProcessRewards()
+	if len(credited) != 0 {
+		t.Errorf("ProcessRewards(): reward must be held, credited = %v", credited)
+		return
+	}
+
+	now = now.Add(48 * time.Hour)
+	credited = s.ProcessRewards()
+	if len(credited) != 1 || account.Balance != 802_00 {
+		t.Errorf("ProcessRewards(): wrong credited = %v, account = %v", credited, account)
+		return
+	}
+
+	totals, err := s.RewardTotals(account.ID)
+	if err != nil || totals.Credited != 2_00 || totals.Pending != 0 {
+		t.Errorf("RewardTotals(): wrong totals = %v, error = %v", totals, err)
+		return
+	}
+}
+
+func TestService_Reject_clawBack(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	err := s.SetCashback("auto", 500)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	account, err := s.addAccountWithBalance("+992000000001", 1_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	pending, err := s.Pay(account.ID, 100_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	credited, err := s.Pay(account.ID, 200_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.ConfirmPayment(credited.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = now.Add(DefaultRewardHoldingPeriod)
+	_, err = s.ConfirmPayment(pending.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	s.ProcessRewards()
+	if account.Balance != 710_00 {
+		t.Errorf("ProcessRewards(): wrong balance = %v", account.Balance)
+		return
+	}
+
+	err = s.Reject(pending.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(credited.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if account.Balance != 1_000_00 {
+		t.Errorf("Reject(): cashback must be clawed back, balance = %v", account.Balance)
+		return
+	}
+
+	totals, err := s.RewardTotals(account.ID)
+	if err != nil || totals.ClawedBack != 10_00 || totals.Pending != 0 || totals.Credited != 0 {
+		t.Errorf("RewardTotals(): wrong totals = %v, error = %v", totals, err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	history, err := imported.RewardHistory(account.ID)
+	if err != nil || len(history) != 2 || history[0].Status != types.RewardStatusClawedBack || history[1].Status != types.RewardStatusCancelled {
+		t.Errorf("Import(): rewards must be persisted, history = %v, error = %v", history, err)
+		return
+	}
+}
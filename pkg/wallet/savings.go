@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrInvalidInterestRate = errors.New("invalid interest rate")
+var ErrNotSavingsAccount = errors.New("account is not a savings account")
+
+// Проценты копятся в миллионных долях минимальной единицы Money.
+const accruedScale = 1_000_000
+
+// OpenSavingsAccount - открывает клиенту сберегательный счёт с годовой ставкой
+// rate в базисных пунктах (100 = 1% годовых).
+func (s *Service) OpenSavingsAccount(customerID int64, name string, rate int64) (*types.Account, error) {
+	if rate < 0 {
+		return nil, ErrInvalidInterestRate
+	}
+
+	account, err := s.OpenAccount(customerID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Type = types.AccountTypeSavings
+	account.InterestRate = rate
+	account.AccruedAt = startOfDay(s.now()).Unix()
+	return account, nil
+}
+
+// SetInterestRate - меняет ставку сберегательного счёта. Проценты по старой
+// ставке предварительно начисляются по текущий день.
+func (s *Service) SetInterestRate(accountID int64, rate int64) error {
+	if rate < 0 {
+		return ErrInvalidInterestRate
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	if account.Type != types.AccountTypeSavings {
+		return ErrNotSavingsAccount
+	}
+
+	s.accrueAccount(account, startOfDay(s.now()))
+	account.InterestRate = rate
+	return nil
+}
+
+// AccrueInterest - начисляет проценты по сберегательным счетам за каждый
+// полностью прошедший день и возвращает выплаты, сделанные при переходе
+// через границу месяца.
+//
+// Правила расчёта:
+//   - за день начисляется balance * rate / 10000 / N, где N - 365 или 366
+//     дней в году, к которому относится день; отрицательный баланс не учитывается;
+//   - дневное начисление считается в миллионных долях Money и округляется вниз;
+//   - 1 числа каждого месяца накопленная сумма выплачивается на счёт целыми
+//     минимальными единицами (округление вниз), дробный остаток переносится
+//     на следующий месяц.
+//
+// Начисление использует текущий баланс счёта, поэтому функцию нужно вызывать
+// ежедневно (например, из планировщика).
+func (s *Service) AccrueInterest() []types.LedgerEntry {
+	today := startOfDay(s.now())
+
+	credited := []types.LedgerEntry{}
+	for _, account := range s.accounts {
+		if account.Type != types.AccountTypeSavings {
+			continue
+		}
+		credited = append(credited, s.accrueAccount(account, today)...)
+	}
+	return credited
+}
+
+// accrueAccount начисляет проценты по счёту за дни до today.
+func (s *Service) accrueAccount(account *types.Account, today time.Time) []types.LedgerEntry {
+	credited := []types.LedgerEntry{}
+
+	day := time.Unix(account.AccruedAt, 0).In(today.Location())
+	if account.AccruedAt == 0 {
+		day = today
+	}
+
+	for day.Before(today) {
+		account.Accrued += dailyInterest(account.Balance, account.InterestRate, day)
+
+		next := day.AddDate(0, 0, 1)
+		if next.Month() != day.Month() && checkCredit(account) == nil {
+			amount := types.Money(account.Accrued / accruedScale)
+			if amount > 0 {
+				account.Balance += amount
+				account.Accrued -= int64(amount) * accruedScale
+				entry := s.addLedgerEntry(account.ID, amount, types.LedgerInterest, "", next)
+				credited = append(credited, *entry)
+			}
+		}
+		day = next
+	}
+
+	account.AccruedAt = today.Unix()
+	return credited
+}
+
+// dailyInterest возвращает проценты за день в миллионных долях Money.
+func dailyInterest(balance types.Money, rate int64, day time.Time) int64 {
+	if balance <= 0 || rate <= 0 {
+		return 0
+	}
+
+	days := int64(365)
+	if isLeapYear(day.Year()) {
+		days = 366
+	}
+	// balance * rate / 10000 * accruedScale / days
+	return int64(balance) * rate * (accruedScale / 10000) / days
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_AccrueInterest(t *testing.T) {
+	tests := []struct {
+		name     string
+		year     int
+		balance  types.Money
+		rate     int64
+		credited types.Money
+		accrued  int64
+	}{
+		{name: "non-leap year", year: 2023, balance: 365_000_00, rate: 1000, credited: 310_000},
+		{name: "leap year", year: 2024, balance: 366_000_00, rate: 1000, credited: 310_000},
+		{name: "fraction carried over", year: 2023, balance: 100_00, rate: 100, credited: 8, accrued: 493_132},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService()
+			now := time.Date(tt.year, time.January, 1, 9, 0, 0, 0, time.UTC)
+			s.SetClock(func() time.Time { return now })
+
+			account, err := s.RegisterAccount("+992000000001")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			savings, err := s.OpenSavingsAccount(account.CustomerID, "savings", tt.rate)
+			if err != nil {
+				t.Errorf("OpenSavingsAccount(): error = %v", err)
+				return
+			}
+			err = s.Deposit(savings.ID, tt.balance)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			now = time.Date(tt.year, time.January, 31, 23, 0, 0, 0, time.UTC)
+			credited := s.AccrueInterest()
+			if len(credited) != 0 {
+				t.Errorf("AccrueInterest(): nothing must be credited before month end, credited = %v", credited)
+				return
+			}
+
+			now = time.Date(tt.year, time.February, 1, 9, 0, 0, 0, time.UTC)
+			credited = s.AccrueInterest()
+			if len(credited) != 1 || credited[0].Amount != tt.credited || credited[0].Kind != types.LedgerInterest {
+				t.Errorf("AccrueInterest(): wrong credited = %v", credited)
+				return
+			}
+			if savings.Balance != tt.balance+tt.credited || savings.Accrued != tt.accrued {
+				t.Errorf("AccrueInterest(): wrong account = %v", savings)
+				return
+			}
+
+			ledger, err := s.AccountLedger(savings.ID)
+			if err != nil || len(ledger) != 2 || ledger[0].Kind != types.LedgerDeposit || ledger[1].Kind != types.LedgerInterest {
+				t.Errorf("AccountLedger(): wrong ledger = %v, error = %v", ledger, err)
+				return
+			}
+		})
+	}
+}
+
+func TestService_SetInterestRate(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2023, time.January, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	account, err := s.addAccountWithBalance("+992000000001", 365_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetInterestRate(account.ID, 100)
+	if err != ErrNotSavingsAccount {
+		t.Errorf("SetInterestRate(): must return ErrNotSavingsAccount, returned: %v", err)
+		return
+	}
+
+	savings, err := s.OpenSavingsAccount(account.CustomerID, "savings", 1000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Transfer(account.ID, savings.ID, 365_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// 10 дней по 10%, затем 21 день по 20%
+	now = time.Date(2023, time.January, 11, 9, 0, 0, 0, time.UTC)
+	err = s.SetInterestRate(savings.ID, 2000)
+	if err != nil {
+		t.Errorf("SetInterestRate(): error = %v", err)
+		return
+	}
+	err = s.SetInterestRate(savings.ID, -1)
+	if err != ErrInvalidInterestRate {
+		t.Errorf("SetInterestRate(): must return ErrInvalidInterestRate, returned: %v", err)
+		return
+	}
+
+	now = time.Date(2023, time.February, 1, 9, 0, 0, 0, time.UTC)
+	s.AccrueInterest()
+	if savings.Balance != 365_000_00+10*10_000+21*20_000 {
+		t.Errorf("AccrueInterest(): wrong balance = %v", savings.Balance)
+		return
+	}
+
+	ledger, err := s.AccountLedger(account.ID)
+	if err != nil || len(ledger) != 2 || ledger[1].Kind != types.LedgerTransferOut || ledger[1].Amount != -365_000_00 {
+		t.Errorf("AccountLedger(): wrong ledger = %v, error = %v", ledger, err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindAccountByID(savings.ID)
+	if err != nil || got.Type != types.AccountTypeSavings || got.InterestRate != 2000 || got.AccruedAt != savings.AccruedAt {
+		t.Errorf("Import(): savings fields must be persisted, account = %v, error = %v", got, err)
+		return
+	}
+	ledger, err = imported.AccountLedger(savings.ID)
+	if err != nil || len(ledger) != 2 {
+		t.Errorf("Import(): ledger must be persisted, ledger = %v, error = %v", ledger, err)
+		return
+	}
+}
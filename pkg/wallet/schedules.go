@@ -0,0 +1,329 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+var ErrInvalidScheduleKind = errors.New("invalid schedule kind")
+
+// RetryPolicy - политика повторных попыток неудавшихся платежей по расписанию.
+type RetryPolicy struct {
+	MaxAttempts int           // сколько раз пытаться выполнить одно плановое списание
+	Interval    time.Duration // пауза между попытками
+}
+
+// DefaultRetryPolicy - политика, используемая, если не задана своя.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Interval: time.Hour}
+
+// ScheduleRun - результат одного запуска платежа по расписанию.
+type ScheduleRun struct {
+	ScheduleID string
+	Time       time.Time
+	Payment    *types.Payment
+	Err        error
+}
+
+// SetRetryPolicy - задаёт политику повторных попыток для планировщика.
+func (s *Service) SetRetryPolicy(policy RetryPolicy) {
+	s.retryPolicy = &policy
+}
+
+// SchedulePayment - создаёт расписание платежей для избранного.
+// Для ScheduleOnce платёж выполнится один раз в момент start, для остальных
+// видов start - первое выполнение. Для ScheduleCron расписание задаётся
+// выражением cron, а первое выполнение - первый подходящий момент не раньше start.
+func (s *Service) SchedulePayment(favoriteID string, kind types.ScheduleKind, start time.Time, cron string) (*types.Schedule, error) {
+	_, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	due := start
+	switch kind {
+	case types.ScheduleOnce, types.ScheduleDaily, types.ScheduleWeekly, types.ScheduleMonthly:
+		cron = ""
+	case types.ScheduleCron:
+		parsed, err := parseCron(cron)
+		if err != nil {
+			return nil, err
+		}
+		if !parsed.matches(start) {
+			next, ok := parsed.next(start)
+			if !ok {
+				return nil, ErrInvalidCron
+			}
+			due = next
+		}
+	default:
+		return nil, ErrInvalidScheduleKind
+	}
+
+	schedule := &types.Schedule{
+		ID:         uuid.New().String(),
+		FavoriteID: favoriteID,
+		Kind:       kind,
+		Cron:       cron,
+		Start:      start.Unix(),
+		Due:        due.Unix(),
+		Next:       due.Unix(),
+		Active:     true,
+	}
+	s.schedules = append(s.schedules, schedule)
+	return schedule, nil
+}
+
+// FindScheduleByID - поиск расписания по идентификатору.
+func (s *Service) FindScheduleByID(scheduleID string) (*types.Schedule, error) {
+	for _, schedule := range s.schedules {
+		if schedule.ID == scheduleID {
+			return schedule, nil
+		}
+	}
+
+	return nil, ErrScheduleNotFound
+}
+
+// CancelSchedule - отключает расписание.
+func (s *Service) CancelSchedule(scheduleID string) error {
+	schedule, err := s.FindScheduleByID(scheduleID)
+	if err != nil {
+		return err
+	}
+
+	schedule.Active = false
+	return nil
+}
+
+// ScheduleRuns - возвращает историю запусков расписания.
+func (s *Service) ScheduleRuns(scheduleID string) ([]ScheduleRun, error) {
+	_, err := s.FindScheduleByID(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []ScheduleRun{}
+	for _, run := range s.scheduleRuns {
+		if run.ScheduleID == scheduleID {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// RunDue - выполняет все платежи, время которых наступило по часам сервиса,
+// и возвращает результаты запусков. Неудачные платежи повторяются согласно
+// политике повторов, после исчерпания попыток расписание переходит
+// к следующему плановому выполнению.
+func (s *Service) RunDue() []ScheduleRun {
+	policy := DefaultRetryPolicy
+	if s.retryPolicy != nil {
+		policy = *s.retryPolicy
+	}
+
+	now := s.now()
+	runs := []ScheduleRun{}
+	for _, schedule := range s.schedules {
+		if !schedule.Active || schedule.Next > now.Unix() {
+			continue
+		}
+
+		payment, err := s.PayFromFavorite(schedule.FavoriteID)
+		run := ScheduleRun{
+			ScheduleID: schedule.ID,
+			Time:       now,
+			Payment:    payment,
+			Err:        err,
+		}
+		runs = append(runs, run)
+		s.scheduleRuns = append(s.scheduleRuns, run)
+
+		if err != nil {
+			log.Print(err)
+			schedule.Attempts++
+			schedule.LastError = err.Error()
+			if schedule.Attempts < policy.MaxAttempts && err != ErrFavoriteNotFound {
+				schedule.Next = now.Add(policy.Interval).Unix()
+				continue
+			}
+		}
+
+		schedule.Attempts = 0
+		if err == nil {
+			schedule.LastError = ""
+		}
+		s.advanceSchedule(schedule, now)
+	}
+
+	return runs
+}
+
+// RunScheduler - запускает цикл планировщика: на каждый сигнал из ticks
+// выполняются RunDue и ProcessInstallments, пока не будет отменён ctx или
+// закрыт ticks. Время запуска берётся из часов сервиса (SetClock), поэтому
+// в тестах циклом можно управлять своим каналом; для работы по реальному
+// времени передайте time.NewTicker(interval).C.
+//
+// Сервис не защищён от конкурентного доступа: запуск выполняется под
+// блокировкой, и пока цикл работает, остальные вызовы сервиса из других
+// горутин нужно выполнять через Locked.
+func (s *Service) RunScheduler(ctx context.Context, ticks <-chan time.Time) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-ticks:
+			if !ok {
+				return
+			}
+			s.Locked(func() {
+				s.RunDue()
+				s.ProcessInstallments()
+			})
+		}
+	}
+}
+
+// Locked - выполняет fn под блокировкой, которую планировщик удерживает
+// на время каждого запуска (см. RunScheduler).
+func (s *Service) Locked(fn func()) {
+	s.schedulerMu.Lock()
+	defer s.schedulerMu.Unlock()
+	fn()
+}
+
+// advanceSchedule переводит расписание на первое плановое выполнение после now.
+// Пропущенные выполнения не наверстываются.
+func (s *Service) advanceSchedule(schedule *types.Schedule, now time.Time) {
+	start := time.Unix(schedule.Start, 0).In(now.Location())
+	due := time.Unix(schedule.Due, 0).In(now.Location())
+
+	switch schedule.Kind {
+	case types.ScheduleDaily, types.ScheduleWeekly:
+		days := 1
+		if schedule.Kind == types.ScheduleWeekly {
+			days = 7
+		}
+		for !due.After(now) {
+			due = due.AddDate(0, 0, days)
+		}
+	case types.ScheduleMonthly:
+		months := monthsBetween(start, due)
+		for !due.After(now) {
+			months++
+			due = addMonthsClamped(start, months)
+		}
+	case types.ScheduleCron:
+		parsed, err := parseCron(schedule.Cron)
+		if err != nil {
+			schedule.Active = false
+			return
+		}
+		next, ok := parsed.next(now)
+		if !ok {
+			schedule.Active = false
+			return
+		}
+		due = next
+	default:
+		schedule.Active = false
+		return
+	}
+
+	schedule.Due = due.Unix()
+	schedule.Next = due.Unix()
+}
+
+// addMonthsClamped прибавляет месяцы к дате; если в месяце нет такого дня
+// (например, 31 число), берётся последний день месяца.
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func monthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// exportSchedules сохраняет расписания в dir/schedules.dump.
+func (s *Service) exportSchedules(dir string) error {
+	if s.schedules == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, schedule := range s.schedules {
+		text := []byte(
+			schedule.ID + ";" +
+				schedule.FavoriteID + ";" +
+				string(schedule.Kind) + ";" +
+				schedule.Cron + ";" +
+				strconv.FormatInt(schedule.Start, 10) + ";" +
+				strconv.FormatInt(schedule.Due, 10) + ";" +
+				strconv.FormatInt(schedule.Next, 10) + ";" +
+				strconv.Itoa(schedule.Attempts) + ";" +
+				strconv.FormatBool(schedule.Active) + ";" +
+				escapeField(schedule.LastError) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "schedules.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importSchedules загружает расписания из dir/schedules.dump, если файл есть.
+func (s *Service) importSchedules(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "schedules.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 10 {
+			continue
+		}
+
+		start, _ := strconv.ParseInt(fields[4], 10, 64)
+		due, _ := strconv.ParseInt(fields[5], 10, 64)
+		next, _ := strconv.ParseInt(fields[6], 10, 64)
+		attempts, _ := strconv.Atoi(fields[7])
+		active, _ := strconv.ParseBool(fields[8])
+
+		schedule, err := s.FindScheduleByID(fields[0])
+		if err != nil {
+			schedule = &types.Schedule{ID: fields[0]}
+			s.schedules = append(s.schedules, schedule)
+		}
+		schedule.FavoriteID = fields[1]
+		schedule.Kind = types.ScheduleKind(fields[2])
+		schedule.Cron = fields[3]
+		schedule.Start = start
+		schedule.Due = due
+		schedule.Next = next
+		schedule.Attempts = attempts
+		schedule.Active = active
+		schedule.LastError = fields[9]
+	}
+}
@@ -0,0 +1,288 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+func TestService_RunDue_monthly(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	_, _, favorites, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorites[0].ID, types.ScheduleMonthly, now, "")
+	if err != nil {
+		t.Errorf("SchedulePayment(): error = %v", err)
+		return
+	}
+
+	runs := s.RunDue()
+	if len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+
+	want := time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)
+	if schedule.Next != want.Unix() {
+		t.Errorf("RunDue(): wrong next run = %v, want %v", time.Unix(schedule.Next, 0).UTC(), want)
+		return
+	}
+
+	now = now.Add(time.Hour)
+	runs = s.RunDue()
+	if len(runs) != 0 {
+		t.Errorf("RunDue(): nothing must be due, runs = %v", runs)
+		return
+	}
+
+	now = want
+	s.RunDue()
+	want = time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC)
+	if schedule.Next != want.Unix() {
+		t.Errorf("RunDue(): wrong next run = %v, want %v", time.Unix(schedule.Next, 0).UTC(), want)
+		return
+	}
+}
+
+func TestService_RunDue_retry(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+	s.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, Interval: time.Hour})
+
+	account, err := s.addAccountWithBalance("+992000000001", 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 100_00, "phone")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payment.ID, "megafon")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorite.ID, types.ScheduleDaily, now, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	runs := s.RunDue()
+	if len(runs) != 1 || runs[0].Err != ErrNotEnoughBalance {
+		t.Errorf("RunDue(): must fail with ErrNotEnoughBalance, runs = %v", runs)
+		return
+	}
+	if schedule.Next != now.Add(time.Hour).Unix() || schedule.Attempts != 1 {
+		t.Errorf("RunDue(): retry must be scheduled, schedule = %v", schedule)
+		return
+	}
+
+	// вторая попытка тоже неудачна - переходим к следующему дню
+	now = now.Add(time.Hour)
+	s.RunDue()
+	if schedule.Next != time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC).Unix() || schedule.Attempts != 0 {
+		t.Errorf("RunDue(): must move to next day, schedule = %v", schedule)
+		return
+	}
+	if schedule.LastError != ErrNotEnoughBalance.Error() {
+		t.Errorf("RunDue(): failure must be recorded, schedule = %v", schedule)
+		return
+	}
+
+	err = s.Deposit(account.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC)
+	runs = s.RunDue()
+	if len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+
+	history, err := s.ScheduleRuns(schedule.ID)
+	if err != nil || len(history) != 3 {
+		t.Errorf("ScheduleRuns(): wrong history = %v, error = %v", history, err)
+		return
+	}
+}
+
+func TestService_SchedulePayment_cron(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC) // понедельник
+	s.SetClock(func() time.Time { return now })
+
+	_, _, favorites, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorites[0].ID, types.ScheduleCron, now, "30 9 * * 1-5")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	want := time.Date(2024, time.January, 2, 9, 30, 0, 0, time.UTC)
+	if schedule.Next != want.Unix() {
+		t.Errorf("SchedulePayment(): wrong first run = %v, want %v", time.Unix(schedule.Next, 0).UTC(), want)
+		return
+	}
+
+	// пятница -> следующий запуск в понедельник
+	now = time.Date(2024, time.January, 5, 9, 30, 0, 0, time.UTC)
+	s.RunDue()
+	want = time.Date(2024, time.January, 8, 9, 30, 0, 0, time.UTC)
+	if schedule.Next != want.Unix() {
+		t.Errorf("RunDue(): wrong next run = %v, want %v", time.Unix(schedule.Next, 0).UTC(), want)
+		return
+	}
+
+	_, err = s.SchedulePayment(favorites[0].ID, types.ScheduleCron, now, "61 * * * *")
+	if err != ErrInvalidCron {
+		t.Errorf("SchedulePayment(): must return ErrInvalidCron, returned: %v", err)
+		return
+	}
+}
+
+func TestService_SchedulePayment_cronStart(t *testing.T) {
+	s := newTestService()
+	_, _, favorites, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  time.Time
+	}{
+		{
+			name:  "exact minute",
+			start: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+			want:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "seconds inside matching minute",
+			start: time.Date(2024, time.January, 1, 10, 0, 30, 0, time.UTC),
+			want:  time.Date(2024, time.January, 1, 11, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := s.SchedulePayment(favorites[0].ID, types.ScheduleCron, tt.start, "0 * * * *")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if schedule.Next != tt.want.Unix() {
+				t.Errorf("SchedulePayment(): wrong first run = %v, want %v", time.Unix(schedule.Next, 0).UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestService_Export_schedules(t *testing.T) {
+	s := newTestService()
+	_, _, favorites, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	schedule, err := s.SchedulePayment(favorites[0].ID, types.ScheduleWeekly, time.Now(), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	schedule.LastError = "ошибка; подробности\nс переводом строки | и \\"
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := imported.FindScheduleByID(schedule.ID)
+	if err != nil {
+		t.Errorf("Import(): schedule not imported, error = %v", err)
+		return
+	}
+	if *got != *schedule {
+		t.Errorf("Import(): wrong schedule = %v, want %v", got, schedule)
+		return
+	}
+}
+
+func TestService_RunScheduler(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	_, _, favorites, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	schedule, err := s.SchedulePayment(favorites[0].ID, types.ScheduleDaily, now.Add(time.Hour), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunScheduler(ctx, ticks)
+	}()
+
+	// рано - платёж не выполняется
+	ticks <- now
+	s.Locked(func() {
+		now = now.Add(time.Hour)
+	})
+	ticks <- now
+	// отправка следующего сигнала дожидается окончания предыдущего запуска
+	ticks <- now
+
+	var runs []ScheduleRun
+	s.Locked(func() {
+		runs, err = s.ScheduleRuns(schedule.ID)
+	})
+	if err != nil || len(runs) != 1 || runs[0].Err != nil {
+		t.Errorf("RunScheduler(): wrong runs = %v, error = %v", runs, err)
+		return
+	}
+
+	cancel()
+	<-done
+}
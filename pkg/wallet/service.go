@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Muhamadi02/wallet/pkg/types"
 	"github.com/google/uuid"
@@ -19,34 +20,82 @@ var ErrAmountMustBePositive = errors.New("amount must be a greater than zero")
 var ErrAccountNotFound = errors.New("account not found")
 var ErrNotEnoughBalance = errors.New("not enough balance")
 var ErrPaymentNotFound = errors.New("payment not found")
+var ErrPaymentRejected = errors.New("payment is rejected")
 var ErrFavoriteNotFound = errors.New("favorite not found")
 
 type Service struct {
-	nextAccountID int64 // для генерации уникального номера аккаунта
-	accounts      []*types.Account
-	payments      []*types.Payment
-	favorites     []*types.Favorite
+	nextAccountID       int64 // для генерации уникального номера аккаунта
+	accounts            []*types.Account
+	payments            []*types.Payment
+	favorites           []*types.Favorite
+	limits              map[limitKey]Limits
+	budgets             []*types.Budget
+	budgetMarks         map[string]budgetMark
+	budgetHandlers      []func(alert BudgetAlert)
+	holds               []*types.Hold
+	schedules           []*types.Schedule
+	scheduleRuns        []ScheduleRun
+	retryPolicy         *RetryPolicy
+	merchants           []*types.Merchant
+	categories          []*types.Category
+	phoneHistory        []*types.PhoneChange
+	customers           []*types.Customer
+	nextCustomerID      int64
+	ledger              []*types.LedgerEntry
+	overdraftPolicy     OverdraftPolicy
+	feeRules            []*FeeRule
+	revenueAccountID    int64
+	feeRefundPolicy     FeeRefundPolicy
+	cashbackRates       map[types.PaymentCategory]int64
+	rewardHoldingPeriod *time.Duration
+	rewards             []*types.Reward
+	moneyRequests       []*types.MoneyRequest
+	vouchers            []*types.Voucher
+	vouchersMu          sync.Mutex // защищает погашение ваучеров от одновременных вызовов
+	invoices            []*types.Invoice
+	installmentPlans    []*types.InstallmentPlan
+	installmentPolicy   *InstallmentPolicy
+	accountIndex        map[int64][]int // номера платежей по счетам, см. paymentsByAccount
+	accountIndexed      int
+	schedulerMu         sync.Mutex // удерживается планировщиком на время запуска, см. Locked
+	clock               func() time.Time
+}
+
+// SetClock - подменяет источник текущего времени (например, в тестах).
+func (s *Service) SetClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// now возвращает текущее время по часам сервиса.
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock()
 }
 
 func (s *Service) RegisterAccount(phone types.Phone) (*types.Account, error) {
-	for _, account := range s.accounts {
-		if account.Phone == phone {
-			return nil, ErrPhoneRegistered
-		}
+	phone, err := NormalizePhone(phone)
+	if err != nil {
+		return nil, err
 	}
 
-	s.nextAccountID++
-	account := &types.Account{
-		ID:      s.nextAccountID,
-		Phone:   phone,
-		Balance: 0,
+	_, err = s.accountByPhone(phone)
+	if err == nil {
+		return nil, ErrPhoneRegistered
 	}
-	s.accounts = append(s.accounts, account)
+
+	_, account := s.newCustomer(phone)
 
 	return account, nil
 }
 
 func (s *Service) Deposit(accountID int64, amount types.Money) error {
+	return s.deposit(accountID, amount, "")
+}
+
+// deposit зачисляет деньги на счёт и записывает зачисление со ссылкой reference.
+func (s *Service) deposit(accountID int64, amount types.Money, reference string) error {
 	if amount <= 0 {
 		return ErrAmountMustBePositive
 	}
@@ -63,12 +112,41 @@ func (s *Service) Deposit(accountID int64, amount types.Money) error {
 		return ErrAccountNotFound
 	}
 
+	err := checkCredit(account)
+	if err != nil {
+		return err
+	}
+
 	account.Balance += amount
+	s.addLedgerEntry(accountID, amount, types.LedgerDeposit, reference, s.now())
 
 	return nil
 }
 
 func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	return s.PayWithOptions(accountID, amount, category, PaymentOptions{})
+}
+
+// PaymentOptions - дополнительные параметры платежа.
+type PaymentOptions struct {
+	Description string               // примечание к платежу
+	FavoriteID  string               // избранное, из которого совершён платёж
+	MerchantID  string               // получатель, на счёт которого зачисляется платёж
+	Channel     types.PaymentChannel // канал платежа, учитывается в правилах комиссий
+	InvoiceID   string               // оплачиваемый счёт; получателем становится выставивший его
+	Tags        []string             // произвольные метки
+	ExternalRef string               // номер заказа во внешней системе
+	Metadata    map[string]string    // произвольные данные "ключ - значение"
+}
+
+// PayWithOptions - совершает платёж с дополнительными параметрами.
+func (s *Service) PayWithOptions(accountID int64, amount types.Money, category types.PaymentCategory, opts PaymentOptions) (*types.Payment, error) {
+	return s.pay(accountID, amount, category, opts, nil)
+}
+
+// pay совершает платёж. Если платёж списывает холд hold, заморозка счёта
+// и лимиты не проверяются, а комиссия не превышает заблокированной в холде.
+func (s *Service) pay(accountID int64, amount types.Money, category types.PaymentCategory, opts PaymentOptions, hold *types.Hold) (*types.Payment, error) {
 	if amount <= 0 {
 		return nil, ErrAmountMustBePositive
 	}
@@ -85,23 +163,127 @@ func (s *Service) Pay(accountID int64, amount types.Money, category types.Paymen
 		return nil, ErrAccountNotFound
 	}
 
-	if account.Balance < amount {
+	var err error
+	if hold == nil {
+		err = checkDebit(account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags, err := normalizeTags(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := copyMetadata(opts.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.InvoiceID != "" {
+		invoice, err := s.payableInvoice(opts.InvoiceID, amount)
+		if err != nil {
+			return nil, err
+		}
+		opts.MerchantID = invoice.MerchantID
+	}
+
+	var merchant *types.Merchant
+	if opts.MerchantID != "" {
+		found, err := s.FindMerchantByID(opts.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+		merchant = found
+		if category == "" {
+			category = merchant.Category
+		}
+	}
+
+	category, err = s.validateCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+
+	fee := s.calculateFee(accountID, amount, category, opts.Channel)
+	if hold != nil && fee > hold.Fee {
+		fee = hold.Fee
+	}
+	if account.Available() < amount+fee {
 		return nil, ErrNotEnoughBalance
 	}
 
-	account.Balance -= amount
+	if hold == nil {
+		err = s.checkLimits(accountID, amount, category)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var revenue *types.Account
+	if fee > 0 {
+		revenue, err = s.revenueAccount()
+		if err != nil && hold == nil {
+			return nil, err
+		}
+		if err != nil {
+			// холд должен списываться всегда: без счёта комиссий комиссия не берётся
+			revenue = nil
+			fee = 0
+		}
+	}
+
+	var settlement *types.Account
+	if merchant != nil {
+		settlement, err = s.FindAccountByID(merchant.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		err = checkCredit(settlement)
+		if err != nil {
+			return nil, err
+		}
+		settlement.Balance += amount
+	}
+
+	account.Balance -= amount + fee
 	paymentID := uuid.New().String()
 	payment := &types.Payment{
-		ID:        paymentID,
-		AccountID: accountID,
-		Amount:    amount,
-		Category:  category,
-		Status:    types.PaymentStatusInProgress,
+		ID:          paymentID,
+		AccountID:   accountID,
+		Amount:      amount,
+		Category:    category,
+		Status:      types.PaymentStatusInProgress,
+		Created:     s.now().Unix(),
+		Description: opts.Description,
+		FavoriteID:  opts.FavoriteID,
+		MerchantID:  opts.MerchantID,
+		Channel:     opts.Channel,
+		Fee:         fee,
+		InvoiceID:   opts.InvoiceID,
+		Tags:        tags,
+		ExternalRef: strings.TrimSpace(opts.ExternalRef),
+		Metadata:    metadata,
+	}
+	if revenue != nil {
+		revenue.Balance += fee
+		s.addLedgerEntry(revenue.ID, fee, types.LedgerFee, paymentID, s.now())
+	}
+	s.addPayment(payment)
+	if payment.InvoiceID != "" {
+		s.addInvoicePayment(payment.InvoiceID, amount)
 	}
-	s.payments = append(s.payments, payment)
 	return payment, nil
 }
 
+// addPayment сохраняет новый платёж и проверяет затронутые им бюджеты.
+func (s *Service) addPayment(payment *types.Payment) {
+	s.payments = append(s.payments, payment)
+	s.checkBudgets(payment)
+}
+
 func (s *Service) FindAccountByID(accountID int64) (*types.Account, error) {
 	for _, account := range s.accounts {
 		if account.ID == accountID {
@@ -133,8 +315,45 @@ func (s *Service) Reject(paymentID string) error {
 		return err
 	}
 
+	if payment.Status == types.PaymentStatusFail {
+		return ErrPaymentRejected
+	}
+
+	// деньги возвращаются плательщику со счёта получателя, поэтому к обоим
+	// счетам применяются обычные правила зачисления и списания
+	err = checkCredit(account)
+	if err != nil {
+		return err
+	}
+
+	if payment.MerchantID != "" {
+		merchant, err := s.FindMerchantByID(payment.MerchantID)
+		if err != nil {
+			return err
+		}
+		settlement, err := s.FindAccountByID(merchant.AccountID)
+		if err != nil {
+			return err
+		}
+		err = checkDebit(settlement)
+		if err != nil {
+			return err
+		}
+		s.ExpireHolds()
+		if settlement.Available() < payment.Amount {
+			return ErrNotEnoughBalance
+		}
+		settlement.Balance -= payment.Amount
+	}
+
 	payment.Status = types.PaymentStatusFail
 	account.Balance += payment.Amount
+	s.refundFee(payment, account)
+	s.clawBackReward(payment, account)
+	if payment.InvoiceID != "" {
+		s.addInvoicePayment(payment.InvoiceID, -payment.Amount)
+	}
+	s.reopenInstallment(payment)
 
 	return nil
 }
@@ -146,7 +365,12 @@ func (s *Service) Repeat(paymentID string)(*types.Payment, error){
 		return nil, err
 	}
 
-	repeatPay, err := s.Pay(payment.AccountID,payment.Amount, payment.Category)
+	repeatPay, err := s.PayWithOptions(payment.AccountID, payment.Amount, payment.Category, PaymentOptions{
+		FavoriteID: payment.FavoriteID,
+		MerchantID: payment.MerchantID,
+		Channel:    payment.Channel,
+		InvoiceID:  payment.InvoiceID,
+	})
 	if err != nil{
 		return nil, err
 	}
@@ -161,13 +385,19 @@ func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorit
 		return nil, err
 	}
 
+	err = s.checkFavoriteName(payment.AccountID, name, "")
+	if err != nil {
+		return nil, err
+	}
+
 	favPaymentID := uuid.New().String()
 	favPayment := &types.Favorite{
 		ID: favPaymentID,
 		AccountID: payment.AccountID,
 		Name: name,
 		Amount: payment.Amount,
-		Categoty: payment.Category,
+		Category: payment.Category,
+		Position: s.countFavorites(payment.AccountID),
 	}
 
 	s.favorites = append(s.favorites, favPayment)
@@ -187,12 +417,25 @@ func (s *Service) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
 
 // PayFromFavorite - совершает платеж из конкретного избранного
 func (s *Service) PayFromFavorite(favoriteID string) (*types.Payment, error) {
+	return s.PayFromFavoriteWith(favoriteID, 0, "")
+}
+
+// PayFromFavoriteWith - совершает платеж из избранного с другой суммой
+// (если amount не равен нулю) и примечанием. Платёж ссылается на избранное.
+func (s *Service) PayFromFavoriteWith(favoriteID string, amount types.Money, description string) (*types.Payment, error) {
 	favPayment, err := s.FindFavoriteByID(favoriteID)
 	if err != nil {
 		return nil, err
 	}
 
-	payment, err := s.Pay(favPayment.AccountID, favPayment.Amount, favPayment.Categoty)
+	if amount == 0 {
+		amount = favPayment.Amount
+	}
+
+	payment, err := s.PayWithOptions(favPayment.AccountID, amount, favPayment.Category, PaymentOptions{
+		Description: description,
+		FavoriteID:  favPayment.ID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -270,7 +513,7 @@ func (s *Service) ImportFromFile(path string) error {
 		tempAccount := strings.Split(tempAcc, ";")
 		id, _ := strconv.ParseInt(tempAccount[0], 10, 64)
 
-		phone := types.Phone(tempAccount[1])
+		phone := normalizeOrRaw(types.Phone(tempAccount[1]))
 
 		balance, _ := strconv.ParseInt(tempAccount[2], 10, 64)
 
@@ -282,6 +525,7 @@ func (s *Service) ImportFromFile(path string) error {
 
 		s.accounts = append(s.accounts, account)
 	}
+	s.linkCustomers()
 	
 	return nil
 }
@@ -298,7 +542,16 @@ func (s *Service) Export(dir string) error {
 			text := []byte(
 				strconv.FormatInt(int64(acc.ID), 10) + ";" + 
 				string(acc.Phone) + ";" + 
-				strconv.FormatInt(int64(acc.Balance), 10) + "\n")
+				strconv.FormatInt(int64(acc.Balance), 10) + ";" +
+				string(acc.Status) + ";" +
+				strconv.FormatInt(acc.CustomerID, 10) + ";" +
+				escapeField(acc.Name) + ";" +
+				string(acc.Type) + ";" +
+				strconv.FormatInt(acc.InterestRate, 10) + ";" +
+				strconv.FormatInt(acc.Accrued, 10) + ";" +
+				strconv.FormatInt(acc.AccruedAt, 10) + ";" +
+				strconv.FormatInt(int64(acc.CreditLimit), 10) + ";" +
+				strconv.FormatInt(acc.ChargedAt, 10) + "\n")
 
 			data = append(data, text...)
 		}
@@ -319,7 +572,17 @@ func (s *Service) Export(dir string) error {
 				strconv.FormatInt(int64(payment.AccountID), 10) + ";" + 
 				strconv.FormatInt(int64(payment.Amount), 10) + ";" +
 				string(payment.Category) + ";" + 
-				string(payment.Status) + "\n")
+				string(payment.Status) + ";" +
+				strconv.FormatInt(payment.Created, 10) + ";" +
+				payment.FavoriteID + ";" +
+				escapeField(payment.Description) + ";" +
+				payment.MerchantID + ";" +
+				string(payment.Channel) + ";" +
+				strconv.FormatInt(int64(payment.Fee), 10) + ";" +
+				payment.InvoiceID + ";" +
+				joinList(payment.Tags) + ";" +
+				escapeField(payment.ExternalRef) + ";" +
+				joinMap(payment.Metadata) + "\n")
 
 			data = append(data, text...)
 		}
@@ -338,9 +601,11 @@ func (s *Service) Export(dir string) error {
 			text := []byte(
 				string(favorite.ID) + ";" + 
 				strconv.FormatInt(int64(favorite.AccountID), 10) + ";" + 
-				string(favorite.Name) + ";" +
+				escapeField(favorite.Name) + ";" +
 				strconv.FormatInt(int64(favorite.Amount), 10) + ";" +
-				string(favorite.Categoty) + "\n")
+				string(favorite.Category) + ";" +
+				strconv.Itoa(favorite.Position) + ";" +
+				escapeField(favorite.Payee) + "\n")
 			
 			data = append(data, text...)
 		}
@@ -352,6 +617,66 @@ func (s *Service) Export(dir string) error {
 		}
 	}
 
+	err := s.exportSchedules(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportMerchants(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportCategories(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportPhoneHistory(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportCustomers(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportLedger(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportRewards(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportMoneyRequests(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportVouchers(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportInvoices(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportInstallments(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.exportHolds(path)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -364,6 +689,8 @@ func (s *Service) Import(dir string) error {
 		path = dir
 	}
 
+	s.importCustomers(path)
+
 	// import accounts
 	accFile, err1 := os.ReadFile(path + "/accounts.dump")
 	if err1 == nil {
@@ -378,23 +705,68 @@ func (s *Service) Import(dir string) error {
 			if len(accImp) == 0 {
 				break
 			}
-			accStr := strings.Split(accImp, ";")
+			accStr := splitFields(accImp)
 			log.Print("accStr", accStr)
 
 			id, _ := strconv.ParseInt(accStr[0], 10, 64)
-			phone := types.Phone(accStr[1])
+			phone := normalizeOrRaw(types.Phone(accStr[1]))
 			balance, _ := strconv.ParseInt(accStr[2], 10, 64)
+			status := types.AccountStatusActive
+			if len(accStr) > 3 && accStr[3] != "" {
+				status = types.AccountStatus(accStr[3])
+			}
+			var customerID int64
+			name := ""
+			if len(accStr) > 5 {
+				customerID, _ = strconv.ParseInt(accStr[4], 10, 64)
+				name = accStr[5]
+			}
+			accountType := types.AccountTypeCurrent
+			var rate, accrued, accruedAt int64
+			if len(accStr) > 9 {
+				if accStr[6] != "" {
+					accountType = types.AccountType(accStr[6])
+				}
+				rate, _ = strconv.ParseInt(accStr[7], 10, 64)
+				accrued, _ = strconv.ParseInt(accStr[8], 10, 64)
+				accruedAt, _ = strconv.ParseInt(accStr[9], 10, 64)
+			}
+			var creditLimit, chargedAt int64
+			if len(accStr) > 11 {
+				creditLimit, _ = strconv.ParseInt(accStr[10], 10, 64)
+				chargedAt, _ = strconv.ParseInt(accStr[11], 10, 64)
+			}
 
 			accFind, _ := s.FindAccountByID(id)
 			if accFind != nil {
 				accFind.Phone = phone
 				accFind.Balance = types.Money(balance)
+				accFind.Status = status
+				if customerID != 0 {
+					accFind.CustomerID = customerID
+					accFind.Name = name
+				}
+				accFind.Type = accountType
+				accFind.InterestRate = rate
+				accFind.Accrued = accrued
+				accFind.AccruedAt = accruedAt
+				accFind.CreditLimit = types.Money(creditLimit)
+				accFind.ChargedAt = chargedAt
 			}else {
 				s.nextAccountID++
 				account := &types.Account{
 					ID: id,
 					Phone: phone,
 					Balance: types.Money(balance),
+					Status: status,
+					CustomerID: customerID,
+					Name: name,
+					Type: accountType,
+					InterestRate: rate,
+					Accrued: accrued,
+					AccruedAt: accruedAt,
+					CreditLimit: types.Money(creditLimit),
+					ChargedAt: chargedAt,
 				}
 				s.accounts = append(s.accounts, account)
 				log.Print(account)
@@ -403,6 +775,7 @@ func (s *Service) Import(dir string) error {
 	}else {
 		log.Print(err1)
 	}
+	s.linkCustomers()
 
 	// import payments
 	payFile, err2 := os.ReadFile(path + "/payments.dump")
@@ -417,7 +790,7 @@ func (s *Service) Import(dir string) error {
 			if len(payImp) == 0 {
 				break
 			}
-			payStr := strings.Split(payImp, ";")
+			payStr := splitFields(payImp)
 			log.Print("payStr : ", payStr)
 
 			id := payStr[0]
@@ -425,13 +798,58 @@ func (s *Service) Import(dir string) error {
 			amount, _ := strconv.ParseInt(payStr[2], 10, 64)
 			category := types.PaymentCategory(payStr[3])
 			status := types.PaymentStatus(payStr[4])
+			var created int64
+			if len(payStr) > 5 {
+				created, _ = strconv.ParseInt(payStr[5], 10, 64)
+			}
+			favoriteID, description := "", ""
+			if len(payStr) > 7 {
+				favoriteID = payStr[6]
+				description = payStr[7]
+			}
+			merchantID := ""
+			if len(payStr) > 8 {
+				merchantID = payStr[8]
+			}
+			channel := types.PaymentChannel("")
+			var fee int64
+			if len(payStr) > 10 {
+				channel = types.PaymentChannel(payStr[9])
+				fee, _ = strconv.ParseInt(payStr[10], 10, 64)
+			}
+			invoiceID := ""
+			if len(payStr) > 11 {
+				invoiceID = payStr[11]
+			}
+			var tags []string
+			externalRef := ""
+			var metadata map[string]string
+			if len(payStr) > 14 {
+				tags = splitList(payStr[12])
+				externalRef = payStr[13]
+				metadata = splitMap(payStr[14])
+			}
 
 			payAcc, _ := s.FindPaymentById(id)
 			if payAcc != nil {
+				if payAcc.AccountID != accountID {
+					// платёж сменил счёт - индекс перестраивается при следующем запросе
+					s.accountIndex = nil
+				}
 				payAcc.AccountID = accountID
 				payAcc.Amount = types.Money(amount)
 				payAcc.Category = category
 				payAcc.Status = status
+				payAcc.Created = created
+				payAcc.FavoriteID = favoriteID
+				payAcc.Description = description
+				payAcc.MerchantID = merchantID
+				payAcc.Channel = channel
+				payAcc.Fee = types.Money(fee)
+				payAcc.InvoiceID = invoiceID
+				payAcc.Tags = tags
+				payAcc.ExternalRef = externalRef
+				payAcc.Metadata = metadata
 			} else {
 				payment := &types.Payment{
 					ID: id,
@@ -439,6 +857,16 @@ func (s *Service) Import(dir string) error {
 					Amount: types.Money(amount),
 					Category: category,
 					Status: status,
+					Created: created,
+					FavoriteID: favoriteID,
+					Description: description,
+					MerchantID: merchantID,
+					Channel: channel,
+					Fee: types.Money(fee),
+					InvoiceID: invoiceID,
+					Tags: tags,
+					ExternalRef: externalRef,
+					Metadata: metadata,
 				}
 				s.payments = append(s.payments, payment)
 				log.Print(payment)
@@ -463,7 +891,7 @@ func (s *Service) Import(dir string) error {
 			if len(favOperation) == 0 {
 				break
 			}
-			favStr := strings.Split(favOperation, ";")
+			favStr := splitFields(favOperation)
 			log.Println("favStr:", favStr)
 
 			id := favStr[0]
@@ -471,20 +899,39 @@ func (s *Service) Import(dir string) error {
 			name := favStr[2]
 			amount, _ := strconv.ParseInt(favStr[3], 10, 64)
 			category := types.PaymentCategory(favStr[4])
+
+			// в старых дампах нет позиции - ставим избранное в конец списка
+			position := -1
+			if len(favStr) > 5 {
+				position, _ = strconv.Atoi(favStr[5])
+			}
+			payee := ""
+			if len(favStr) > 6 {
+				payee = favStr[6]
+			}
 			
 			favAcc, _ := s.FindFavoriteByID(id)
 			if favAcc != nil {
 				favAcc.AccountID = accountID
 				favAcc.Name = name
 				favAcc.Amount = types.Money(amount)
-				favAcc.Categoty = category
+				favAcc.Category = category
+				if position >= 0 {
+					favAcc.Position = position
+				}
+				favAcc.Payee = payee
 			} else {
+				if position < 0 {
+					position = s.countFavorites(accountID)
+				}
 				favorite := &types.Favorite{
 					ID:        id,
 					AccountID: accountID,
 					Name:      name,
 					Amount:    types.Money(amount),
-					Categoty:  category,
+					Category:  category,
+					Position:  position,
+					Payee:     payee,
 				}
 				s.favorites = append(s.favorites, favorite)
 				log.Print(favorite)
@@ -494,10 +941,23 @@ func (s *Service) Import(dir string) error {
 		log.Println(err3)
 	}
 
+	s.importSchedules(path)
+	s.importMerchants(path)
+	s.importCategories(path)
+	s.importPhoneHistory(path)
+	s.importLedger(path)
+	s.importRewards(path)
+	s.importMoneyRequests(path)
+	s.importVouchers(path)
+	s.importInvoices(path)
+	s.importInstallments(path)
+	s.importHolds(path)
+
 	return nil
 }
 
-// ExportAccountHistory - выводить все платежи конкретного аккаунта
+// ExportAccountHistory - выводить все платежи конкретного аккаунта (пустой
+// список, если платежей нет). Для постраничного вывода см. AccountHistory.
 func (s *Service) ExportAccountHistory(accountID int64) ([]types.Payment, error) {
 	
 	_, err := s.FindAccountByID(accountID)
@@ -506,20 +966,18 @@ func (s *Service) ExportAccountHistory(accountID int64) ([]types.Payment, error)
 	}
 
 	payments := []types.Payment{}
-	for _, payment := range s.payments {
+	for _, index := range s.paymentsByAccount()[accountID] {
+		payment := s.payments[index]
 		if payment.AccountID == accountID {
 			payments = append(payments, *payment)
 		}
 	}
 
-	if len(payments) <= 0 || payments == nil {
-		return nil, ErrPaymentNotFound
-	}
-
 	return payments, nil
 }
 
 // HistoryToFiles - сохраняеть результаты функции ExportAccountHistory в файл.
+// Строка файла: id;accountID;amount;category;status;description;tags;externalRef;metadata.
 func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records int) error {
 
 	_, cerr := os.Stat(dir)
@@ -543,7 +1001,8 @@ func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records i
 				strconv.FormatInt(int64(payment.AccountID), 10) + ";" +
 				strconv.FormatInt(int64(payment.Amount), 10) + ";" +
 				string(payment.Category) + ";" +
-				string(payment.Status) + "\n")
+				string(payment.Status) + ";" +
+				historyDetails(payment) + "\n")
 
 			data = append(data, text...)
 		}
@@ -562,7 +1021,8 @@ func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records i
 					strconv.FormatInt(int64(payment.AccountID), 10) + ";" +
 					strconv.FormatInt(int64(payment.Amount), 10) + ";" +
 					string(payment.Category) + ";" +
-					string(payment.Status) + "\n")
+					string(payment.Status) + ";" +
+					historyDetails(payment) + "\n")
 
 			data = append(data, text...)
 
@@ -581,6 +1041,15 @@ func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records i
 	return nil
 }
 
+// historyDetails возвращает поля примечания, меток, внешнего номера и данных
+// платежа для строки HistoryToFiles.
+func historyDetails(payment types.Payment) string {
+	return escapeField(payment.Description) + ";" +
+		joinList(payment.Tags) + ";" +
+		escapeField(payment.ExternalRef) + ";" +
+		joinMap(payment.Metadata)
+}
+
 // SumPayments - суммирует платежи с помощью горутин
 func (s *Service) SumPayments(goroutines int) types.Money {
 
@@ -666,8 +1135,10 @@ func (s *Service) FilterPayments(accountID int64, goroutines int) ([]types.Payme
 }
 
 // FilterCategory ставит нужную категорию.
+//
+// Deprecated: используйте NewCategoryFilter, например NewCategoryFilter("auto").Build().
 func FilterCategory(payment types.Payment) bool {
-	return payment.Category == "auto"
+	return NewCategoryFilter("auto").Build()(payment)
 }
 
 // FilterPaymentsByFn фильтрует платежи по любим функциям.
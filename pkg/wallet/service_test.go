@@ -425,7 +425,7 @@ func TestService_PayFromFavorite_success(t *testing.T) {
 		return
 	}
 
-	if payment.Category != favorite.Categoty {
+	if payment.Category != favorite.Category {
 		t.Errorf("PayFromFavorite(): category of payment difference, \n Current payment = %v, \n favorite payment = %v", payment, favorite)
 		return
 	}
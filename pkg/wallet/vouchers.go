@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Muhamadi02/wallet/pkg/types"
+)
+
+var ErrVoucherNotFound = errors.New("voucher not found")
+var ErrVoucherExpired = errors.New("voucher is expired")
+var ErrVoucherUsedUp = errors.New("voucher is used up")
+var ErrVoucherAlreadyRedeemed = errors.New("voucher is already redeemed by this account")
+var ErrInvalidVoucherUses = errors.New("invalid voucher uses")
+
+// DefaultVoucherTTL - срок действия ваучера, если он не задан явно.
+const DefaultVoucherTTL = 365 * 24 * time.Hour
+
+// Код ваучера - 16 символов из алфавита без похожих друг на друга букв
+// (80 случайных бит), разбитые на группы по 4: XXXX-XXXX-XXXX-XXXX.
+const (
+	voucherAlphabet  = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	voucherCodeLen   = 16
+	voucherGroupSize = 4
+)
+
+// IssueVoucher - выпускает ваучер на amount, который можно погасить maxUses
+// раз (каждым аккаунтом не более одного раза) в течение ttl.
+func (s *Service) IssueVoucher(amount types.Money, maxUses int, ttl time.Duration) (*types.Voucher, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+	if maxUses < 1 {
+		return nil, ErrInvalidVoucherUses
+	}
+	if ttl <= 0 {
+		ttl = DefaultVoucherTTL
+	}
+
+	s.vouchersMu.Lock()
+	defer s.vouchersMu.Unlock()
+
+	code, err := s.newVoucherCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	voucher := &types.Voucher{
+		Code:    code,
+		Amount:  amount,
+		MaxUses: maxUses,
+		Created: now.Unix(),
+		Expires: now.Add(ttl).Unix(),
+	}
+	s.vouchers = append(s.vouchers, voucher)
+	return voucher, nil
+}
+
+// FindVoucherByCode - поиск ваучера по коду. Регистр, пробелы и дефисы в коде не важны.
+func (s *Service) FindVoucherByCode(code string) (*types.Voucher, error) {
+	s.vouchersMu.Lock()
+	defer s.vouchersMu.Unlock()
+
+	return s.voucherByCode(code)
+}
+
+// RedeemVoucher - гасит ваучер: сумма ваучера зачисляется на счёт, а в
+// движениях по счёту сохраняется ссылка на ваучер. Одновременные вызовы
+// RedeemVoucher безопасны: ваучер не будет погашен больше MaxUses раз.
+func (s *Service) RedeemVoucher(code string, accountID int64) error {
+	s.vouchersMu.Lock()
+	defer s.vouchersMu.Unlock()
+
+	voucher, err := s.voucherByCode(code)
+	if err != nil {
+		return err
+	}
+	if voucher.Expires <= s.now().Unix() {
+		return ErrVoucherExpired
+	}
+	if voucher.Uses >= voucher.MaxUses {
+		return ErrVoucherUsedUp
+	}
+
+	reference := voucherReference(voucher.Code)
+	for _, entry := range s.ledger {
+		if entry.AccountID == accountID && entry.Reference == reference {
+			return ErrVoucherAlreadyRedeemed
+		}
+	}
+
+	err = s.deposit(accountID, voucher.Amount, reference)
+	if err != nil {
+		return err
+	}
+
+	voucher.Uses++
+	return nil
+}
+
+// voucherByCode ищет ваучер по коду; вызывается под vouchersMu.
+func (s *Service) voucherByCode(code string) (*types.Voucher, error) {
+	code = normalizeVoucherCode(code)
+	for _, voucher := range s.vouchers {
+		if voucher.Code == code {
+			return voucher, nil
+		}
+	}
+
+	return nil, ErrVoucherNotFound
+}
+
+// newVoucherCode генерирует код, которого ещё нет среди выпущенных ваучеров.
+func (s *Service) newVoucherCode() (string, error) {
+	for {
+		random := make([]byte, voucherCodeLen)
+		_, err := rand.Read(random)
+		if err != nil {
+			return "", err
+		}
+
+		code := make([]byte, voucherCodeLen)
+		for i, b := range random {
+			// 256 делится на 32 нацело, поэтому символы распределены равномерно
+			code[i] = voucherAlphabet[int(b)%len(voucherAlphabet)]
+		}
+
+		formatted := normalizeVoucherCode(string(code))
+		_, err = s.voucherByCode(formatted)
+		if err == ErrVoucherNotFound {
+			return formatted, nil
+		}
+	}
+}
+
+// normalizeVoucherCode приводит введённый код к виду XXXX-XXXX-XXXX-XXXX.
+func normalizeVoucherCode(code string) string {
+	compact := make([]rune, 0, voucherCodeLen)
+	for _, r := range strings.ToUpper(code) {
+		if r == '-' || r == ' ' {
+			continue
+		}
+		compact = append(compact, r)
+	}
+
+	var b strings.Builder
+	for i, r := range compact {
+		if i > 0 && i%voucherGroupSize == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// voucherReference возвращает ссылку на ваучер для движений по счёту.
+func voucherReference(code string) string {
+	return "voucher:" + code
+}
+
+// exportVouchers сохраняет ваучеры в dir/vouchers.dump.
+func (s *Service) exportVouchers(dir string) error {
+	s.vouchersMu.Lock()
+	defer s.vouchersMu.Unlock()
+
+	if s.vouchers == nil {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for _, voucher := range s.vouchers {
+		text := []byte(
+			voucher.Code + ";" +
+				strconv.FormatInt(int64(voucher.Amount), 10) + ";" +
+				strconv.Itoa(voucher.MaxUses) + ";" +
+				strconv.Itoa(voucher.Uses) + ";" +
+				strconv.FormatInt(voucher.Created, 10) + ";" +
+				strconv.FormatInt(voucher.Expires, 10) + "\n")
+
+		data = append(data, text...)
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "vouchers.dump"), data, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	return nil
+}
+
+// importVouchers загружает ваучеры из dir/vouchers.dump, если файл есть.
+func (s *Service) importVouchers(dir string) {
+	file, err := os.ReadFile(filepath.Join(dir, "vouchers.dump"))
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	s.vouchersMu.Lock()
+	defer s.vouchersMu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := splitFields(line)
+		if len(fields) < 6 {
+			continue
+		}
+
+		amount, _ := strconv.ParseInt(fields[1], 10, 64)
+		maxUses, _ := strconv.Atoi(fields[2])
+		uses, _ := strconv.Atoi(fields[3])
+		created, _ := strconv.ParseInt(fields[4], 10, 64)
+		expires, _ := strconv.ParseInt(fields[5], 10, 64)
+
+		voucher, err := s.voucherByCode(fields[0])
+		if err != nil {
+			voucher = &types.Voucher{Code: normalizeVoucherCode(fields[0])}
+			s.vouchers = append(s.vouchers, voucher)
+		}
+		voucher.Amount = types.Money(amount)
+		voucher.MaxUses = maxUses
+		voucher.Uses = uses
+		voucher.Created = created
+		voucher.Expires = expires
+	}
+}
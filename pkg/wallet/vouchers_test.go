@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_RedeemVoucher(t *testing.T) {
+	s := newTestService()
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+
+	first, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	second, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	voucher, err := s.IssueVoucher(100_00, 2, 24*time.Hour)
+	if err != nil {
+		t.Errorf("IssueVoucher(): error = %v", err)
+		return
+	}
+	if len(voucher.Code) != 19 || strings.Count(voucher.Code, "-") != 3 {
+		t.Errorf("IssueVoucher(): wrong code = %v", voucher.Code)
+		return
+	}
+
+	code := strings.ToLower(strings.ReplaceAll(voucher.Code, "-", ""))
+	err = s.RedeemVoucher(code, first.ID)
+	if err != nil {
+		t.Errorf("RedeemVoucher(): error = %v", err)
+		return
+	}
+	if first.Balance != 100_00 {
+		t.Errorf("RedeemVoucher(): wrong balance = %v", first.Balance)
+		return
+	}
+	ledger, err := s.AccountLedger(first.ID)
+	if err != nil || len(ledger) != 1 || ledger[0].Reference != "voucher:"+voucher.Code {
+		t.Errorf("AccountLedger(): wrong ledger = %v, error = %v", ledger, err)
+		return
+	}
+
+	err = s.RedeemVoucher(voucher.Code, first.ID)
+	if err != ErrVoucherAlreadyRedeemed {
+		t.Errorf("RedeemVoucher(): must return ErrVoucherAlreadyRedeemed, returned: %v", err)
+		return
+	}
+	err = s.RedeemVoucher(voucher.Code, second.ID)
+	if err != nil {
+		t.Errorf("RedeemVoucher(): error = %v", err)
+		return
+	}
+	err = s.RedeemVoucher(voucher.Code, 3)
+	if err != ErrVoucherUsedUp {
+		t.Errorf("RedeemVoucher(): must return ErrVoucherUsedUp, returned: %v", err)
+		return
+	}
+
+	expired, err := s.IssueVoucher(100_00, 1, time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	now = now.Add(time.Hour)
+	err = s.RedeemVoucher(expired.Code, first.ID)
+	if err != ErrVoucherExpired {
+		t.Errorf("RedeemVoucher(): must return ErrVoucherExpired, returned: %v", err)
+		return
+	}
+
+	err = s.RedeemVoucher("AAAA-BBBB-CCCC-DDDD", first.ID)
+	if err != ErrVoucherNotFound {
+		t.Errorf("RedeemVoucher(): must return ErrVoucherNotFound, returned: %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	got, err := imported.FindVoucherByCode(voucher.Code)
+	if err != nil || got.Uses != 2 || got.MaxUses != 2 || got.Amount != 100_00 {
+		t.Errorf("Import(): voucher must be persisted, voucher = %v, error = %v", got, err)
+		return
+	}
+}
+
+func TestService_RedeemVoucher_concurrent(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	voucher, err := s.IssueVoucher(100_00, 1, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	redeemed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.RedeemVoucher(voucher.Code, account.ID) == nil {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if redeemed != 1 || account.Balance != 100_00 {
+		t.Errorf("RedeemVoucher(): voucher must be redeemed once, redeemed = %v, balance = %v", redeemed, account.Balance)
+		return
+	}
+}